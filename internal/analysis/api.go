@@ -0,0 +1,213 @@
+package analysis
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+// API maps each exported identifier of a Go package, such as `NewClient`,
+// `Client.Commit` or `Config.Style`, to its signature. Parameter names are
+// left out of the signatures, as renaming them breaks nothing.
+type API map[string]string
+
+// ParseAPI adds the exported API declared in the Go source to the map.
+func (api API) ParseAPI(filename string, src []byte) error {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.SkipObjectResolution)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse %s", filename)
+	}
+	if file.Name.Name == "main" {
+		// commands cannot be imported, so have no API
+		return nil
+	}
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			api.addFunc(decl)
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					api.addType(spec)
+				case *ast.ValueSpec:
+					api.addValue(decl.Tok, spec)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (api API) addFunc(decl *ast.FuncDecl) {
+	if !decl.Name.IsExported() {
+		return
+	}
+	name := decl.Name.Name
+	if decl.Recv != nil && len(decl.Recv.List) > 0 {
+		receiver := receiverName(decl.Recv.List[0].Type)
+		if !ast.IsExported(receiver) {
+			return
+		}
+		name = receiver + "." + name
+	}
+	api[name] = "func" + typeParams(decl.Type.TypeParams) + signature(decl.Type)
+}
+
+func (api API) addType(spec *ast.TypeSpec) {
+	if !spec.Name.IsExported() {
+		return
+	}
+	name := spec.Name.Name
+	prefix := "type" + typeParams(spec.TypeParams)
+	if spec.Assign.IsValid() {
+		prefix += " ="
+	}
+
+	structType, ok := spec.Type.(*ast.StructType)
+	if !ok {
+		api[name] = prefix + " " + exprString(spec.Type)
+		return
+	}
+
+	// the fields are compared one by one, so that adding one breaks nothing
+	api[name] = prefix + " struct"
+	for _, field := range structType.Fields.List {
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{{Name: receiverName(field.Type)}}
+		}
+		for _, ident := range names {
+			if ast.IsExported(ident.Name) {
+				api[name+"."+ident.Name] = exprString(field.Type)
+			}
+		}
+	}
+}
+
+func (api API) addValue(tok token.Token, spec *ast.ValueSpec) {
+	for _, ident := range spec.Names {
+		if !ident.IsExported() {
+			continue
+		}
+		signature := tok.String()
+		if spec.Type != nil {
+			signature += " " + exprString(spec.Type)
+		}
+		api[ident.Name] = signature
+	}
+}
+
+// Change is a change to an exported identifier that breaks the code using it.
+type Change struct {
+	Package string // the directory of the package
+	Name    string
+	Old     string
+	New     string // blank when the identifier was removed
+}
+
+func (c Change) String() string {
+	if c.New == "" {
+		return c.Package + ": " + c.Name + " was removed"
+	}
+	return c.Package + ": " + c.Name + " changed from `" + c.Old + "` to `" + c.New + "`"
+}
+
+// Summary describes the changes in a single line, for a commit message footer.
+func Summary(changes []Change) string {
+	const limit = 3
+
+	var parts []string
+	for i, change := range changes {
+		if i == limit {
+			parts = append(parts, fmt.Sprintf("and %d more", len(changes)-limit))
+			break
+		}
+		if change.New == "" {
+			parts = append(parts, change.Name+" was removed")
+		} else {
+			parts = append(parts, "the signature of "+change.Name+" changed")
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Compare lists the identifiers of the old API that were removed from, or
+// changed in, the new API, sorted by name.
+func Compare(pkg string, old, new API) []Change {
+	var changes []Change
+	for name, signature := range old {
+		if newSignature, ok := new[name]; !ok || newSignature != signature {
+			changes = append(changes, Change{Package: pkg, Name: name, Old: signature, New: newSignature})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+func receiverName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(expr.X)
+	case *ast.IndexExpr:
+		return receiverName(expr.X)
+	case *ast.IndexListExpr:
+		return receiverName(expr.X)
+	case *ast.SelectorExpr:
+		return expr.Sel.Name
+	case *ast.Ident:
+		return expr.Name
+	}
+	return ""
+}
+
+func signature(fn *ast.FuncType) string {
+	results := fieldTypes(fn.Results)
+	switch {
+	case len(results) == 0:
+		return "(" + strings.Join(fieldTypes(fn.Params), ", ") + ")"
+	case len(results) == 1:
+		return "(" + strings.Join(fieldTypes(fn.Params), ", ") + ") " + results[0]
+	default:
+		return "(" + strings.Join(fieldTypes(fn.Params), ", ") + ") (" + strings.Join(results, ", ") + ")"
+	}
+}
+
+func typeParams(fields *ast.FieldList) string {
+	if fields == nil || len(fields.List) == 0 {
+		return ""
+	}
+	return "[" + strings.Join(fieldTypes(fields), ", ") + "]"
+}
+
+// fieldTypes lists the type of each parameter, once per name.
+func fieldTypes(fields *ast.FieldList) []string {
+	if fields == nil {
+		return nil
+	}
+	var list []string
+	for _, field := range fields.List {
+		count := max(len(field.Names), 1)
+		for range count {
+			list = append(list, exprString(field.Type))
+		}
+	}
+	return list
+}
+
+func exprString(expr ast.Expr) string {
+	if fn, ok := expr.(*ast.FuncType); ok {
+		return "func" + signature(fn)
+	}
+	return types.ExprString(expr)
+}
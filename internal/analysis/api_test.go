@@ -0,0 +1,139 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const oldSource = `package git
+
+type Scope int
+
+const StagedOnly Scope = 0
+
+var ErrAborted = errors.New("aborted")
+
+type Client struct {
+	Scope Scope
+	Dir   string
+	cache map[string]string
+}
+
+type Runner interface {
+	Run(args ...string) ([]byte, error)
+}
+
+func NewClient(scope Scope) *Client { return nil }
+
+func (c *Client) Commit(message string) error { return nil }
+
+func (c *Client) helper() {}
+
+func Map[T any](items []T, f func(T) T) []T { return nil }
+
+type notExported struct{}
+
+func (n notExported) Exported() {}
+`
+
+func parseAPI(t *testing.T, src string) API {
+	api := API{}
+	require.NoError(t, api.ParseAPI("client.go", []byte(src)))
+	return api
+}
+
+func TestParseAPI(t *testing.T) {
+	api := parseAPI(t, oldSource)
+
+	assert.Equal(t, API{
+		"Scope":         "type int",
+		"StagedOnly":    "const Scope",
+		"ErrAborted":    "var",
+		"Client":        "type struct",
+		"Client.Scope":  "Scope",
+		"Client.Dir":    "string",
+		"Runner":        "type interface{Run(args ...string) ([]byte, error)}",
+		"NewClient":     "func(Scope) *Client",
+		"Client.Commit": "func(string) error",
+		"Map":           "func[any]([]T, func(T) T) []T",
+	}, api)
+}
+
+func TestParseAPI_MainPackage(t *testing.T) {
+	api := parseAPI(t, "package main\n\nfunc Exported() {}\n")
+	assert.Empty(t, api)
+}
+
+func TestParseAPI_SyntaxError(t *testing.T) {
+	api := API{}
+	assert.ErrorContains(t, api.ParseAPI("broken.go", []byte("package git\n\nfunc (")), "failed to parse broken.go")
+}
+
+func TestCompare(t *testing.T) {
+	old := parseAPI(t, oldSource)
+
+	t.Run("NonBreakingChanges", func(t *testing.T) {
+		new := parseAPI(t, oldSource+`
+func (c *Client) Push() error { return nil }
+
+func (c *Client) helper2() {}
+`)
+		new["Client.Extra"] = "bool"
+		assert.Empty(t, Compare("git", old, new))
+	})
+
+	t.Run("RenamedParameter", func(t *testing.T) {
+		new := parseAPI(t, `package git
+
+func (c *Client) Commit(msg string) error { return nil }
+`)
+		changes := Compare("git", API{"Client.Commit": old["Client.Commit"]}, new)
+		assert.Empty(t, changes)
+	})
+
+	t.Run("BreakingChanges", func(t *testing.T) {
+		new := parseAPI(t, `package git
+
+type Scope int
+
+const StagedOnly Scope = 0
+
+var ErrAborted = errors.New("aborted")
+
+type Client struct {
+	Scope Scope
+}
+
+type Runner interface {
+	Run(args ...string) ([]byte, error)
+}
+
+func NewClient(scope Scope, dir string) *Client { return nil }
+
+func (c *Client) Commit(message string) error { return nil }
+
+func Map[T any](items []T, f func(T) T) []T { return nil }
+`)
+		changes := Compare("git", old, new)
+		assert.Equal(t, []Change{
+			{Package: "git", Name: "Client.Dir", Old: "string"},
+			{Package: "git", Name: "NewClient", Old: "func(Scope) *Client", New: "func(Scope, string) *Client"},
+		}, changes)
+		assert.Equal(t, "git: Client.Dir was removed", changes[0].String())
+		assert.Equal(t, "git: NewClient changed from `func(Scope) *Client` to `func(Scope, string) *Client`", changes[1].String())
+	})
+}
+
+func TestSummary(t *testing.T) {
+	changes := []Change{
+		{Name: "A"},
+		{Name: "B", Old: "func()", New: "func(int)"},
+		{Name: "C"},
+		{Name: "D"},
+		{Name: "E"},
+	}
+	assert.Equal(t, "A was removed", Summary(changes[:1]))
+	assert.Equal(t, "A was removed, the signature of B changed, C was removed, and 2 more", Summary(changes))
+}
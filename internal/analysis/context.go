@@ -0,0 +1,203 @@
+package analysis
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The amount of context added to each hunk of a diff by Annotate.
+const (
+	ContextNone       = "none"
+	ContextNames      = "names"      // the names of the enclosing functions and types
+	ContextSignatures = "signatures" // their full signatures
+)
+
+// Contexts are the supported amounts of diff context.
+var Contexts = []string{ContextNone, ContextNames, ContextSignatures}
+
+// maxEnclosing limits the declarations named in a single hunk header.
+const maxEnclosing = 3
+
+var oneLine = strings.NewReplacer("( ", "(", ", )", ")", " )", ")")
+
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// declaration is a top-level function or type, spanning the given lines.
+type declaration struct {
+	name      string
+	signature string
+	start     int
+	end       int
+}
+
+// Annotate rewrites the section heading of each hunk header in the diff with
+// the functions and types containing the hunk's changed lines, so that the
+// model can tell what changed without being sent whole files. The new side of
+// each file is read at rev (or as staged, when blank). Only Go files are
+// annotated, and those that cannot be read or parsed are left as they are.
+func Annotate(git FileReader, rev, diff, context string) string {
+	if context == ContextNone || diff == "" {
+		return diff
+	}
+
+	lines := strings.Split(diff, "\n")
+	var decls []declaration
+	var inHunk bool
+	header, match := -1, ""
+	first, last, line := 0, 0, 0
+
+	annotate := func() {
+		if header >= 0 && first > 0 {
+			if heading := enclosing(decls, first, last, context); heading != "" {
+				lines[header] = match + " " + heading
+			}
+		}
+		header, first, last = -1, 0, 0
+	}
+	changed := func(n int) {
+		if first == 0 {
+			first = n
+		}
+		last = n
+	}
+
+	for i, text := range lines {
+		switch {
+		case strings.HasPrefix(text, "diff --git "):
+			annotate()
+			inHunk, decls = false, nil
+
+		case !inHunk && strings.HasPrefix(text, "+++ "):
+			// the git client makes b/ the new side's prefix, whatever git's config
+			path := strings.TrimPrefix(strings.TrimPrefix(text, "+++ "), "b/")
+			if strings.HasSuffix(path, ".go") {
+				if src, err := git.FileAt(rev, path); err == nil {
+					decls = declarations(path, src)
+				}
+			}
+
+		case hunkHeader.MatchString(text):
+			annotate()
+			m := hunkHeader.FindStringSubmatch(text)
+			inHunk = true
+			header, match = i, m[0]
+			line, _ = strconv.Atoi(m[1])
+
+		case !inHunk:
+			// the rest of the file's header, such as its index or mode
+
+		case strings.HasPrefix(text, "+"):
+			changed(line)
+			line++
+		case strings.HasPrefix(text, "-"):
+			// the removed lines were just before this line of the new side
+			changed(max(line, 1))
+		case strings.HasPrefix(text, " "):
+			line++
+		}
+	}
+	annotate()
+
+	return strings.Join(lines, "\n")
+}
+
+func declarations(filename, src string) []declaration {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	line := func(pos token.Pos) int { return fset.Position(pos).Line }
+	text := func(from, to token.Pos) string {
+		// onto one line, as the parameters may be spread over several
+		text := strings.Join(strings.Fields(src[fset.Position(from).Offset:fset.Position(to).Offset]), " ")
+		return oneLine.Replace(text)
+	}
+
+	var decls []declaration
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			name := decl.Name.Name
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				name = receiverName(decl.Recv.List[0].Type) + "." + name
+			}
+			end := decl.End()
+			if decl.Body != nil {
+				end = decl.Body.Lbrace
+			}
+			start := decl.Pos()
+			if decl.Doc != nil {
+				start = decl.Doc.Pos()
+			}
+			decls = append(decls, declaration{
+				name:      name,
+				signature: strings.TrimSpace(text(decl.Pos(), end)),
+				start:     line(start),
+				end:       line(decl.End()),
+			})
+
+		case *ast.GenDecl:
+			if decl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				spec := spec.(*ast.TypeSpec)
+				start := spec.Pos()
+				if len(decl.Specs) == 1 && decl.Doc != nil {
+					start = decl.Doc.Pos()
+				} else if spec.Doc != nil {
+					start = spec.Doc.Pos()
+				}
+				decls = append(decls, declaration{
+					name:      spec.Name.Name,
+					signature: "type " + text(spec.Pos(), spec.Type.Pos()) + " " + typeKind(spec.Type),
+					start:     line(start),
+					end:       line(spec.End()),
+				})
+			}
+		}
+	}
+	return decls
+}
+
+// typeKind is the start of a type's definition, without its fields or methods.
+func typeKind(expr ast.Expr) string {
+	switch expr.(type) {
+	case *ast.StructType:
+		return "struct"
+	case *ast.InterfaceType:
+		return "interface"
+	default:
+		return exprString(expr)
+	}
+}
+
+// enclosing describes the declarations overlapping the lines of a hunk.
+func enclosing(decls []declaration, start, end int, context string) string {
+	var names []string
+	for _, decl := range decls {
+		if decl.start > end || decl.end < start {
+			continue
+		}
+		if len(names) == maxEnclosing {
+			names = append(names, "…")
+			break
+		}
+		if context == ContextSignatures {
+			names = append(names, decl.signature)
+		} else {
+			names = append(names, decl.name)
+		}
+	}
+
+	if context == ContextSignatures {
+		return strings.Join(names, "; ")
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,129 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const staged = `package git
+
+import "strings"
+
+// Client runs git.
+type Client struct {
+	scope Scope
+}
+
+type Scope int
+
+// Commit commits the staged changes.
+func (c *Client) Commit(
+	message string,
+) error {
+	return run("commit", "-m", strings.TrimSpace(message))
+}
+
+func run(args ...string) error {
+	return nil
+}
+`
+
+const diff = `diff --git a/git/client.go b/git/client.go
+index 1111111..2222222 100644
+--- a/git/client.go
++++ b/git/client.go
+@@ -1,6 +1,6 @@
+ package git
+ 
+-import "fmt"
++import "strings"
+ 
+ // Client runs git.
+ type Client struct {
+@@ -14,7 +14,7 @@ type Scope int
+ 	message string,
+ ) error {
+-	return run("commit", "-m", message)
++	return run("commit", "-m", strings.TrimSpace(message))
+ }
+ 
+ func run(args ...string) error {
+@@ -6,3 +6,3 @@ type Client struct {
+ type Client struct {
+-	scope int
++	scope Scope
+ }
+diff --git a/README.md b/README.md
+--- a/README.md
++++ b/README.md
+@@ -1,1 +1,1 @@ intro
+-old
++new`
+
+func TestAnnotate(t *testing.T) {
+	git := files{":git/client.go": staged}
+
+	t.Run("Names", func(t *testing.T) {
+		expected := `diff --git a/git/client.go b/git/client.go
+index 1111111..2222222 100644
+--- a/git/client.go
++++ b/git/client.go
+@@ -1,6 +1,6 @@
+ package git
+ 
+-import "fmt"
++import "strings"
+ 
+ // Client runs git.
+ type Client struct {
+@@ -14,7 +14,7 @@ Client.Commit
+ 	message string,
+ ) error {
+-	return run("commit", "-m", message)
++	return run("commit", "-m", strings.TrimSpace(message))
+ }
+ 
+ func run(args ...string) error {
+@@ -6,3 +6,3 @@ Client
+ type Client struct {
+-	scope int
++	scope Scope
+ }
+diff --git a/README.md b/README.md
+--- a/README.md
++++ b/README.md
+@@ -1,1 +1,1 @@ intro
+-old
++new`
+		assert.Equal(t, expected, Annotate(git, "", diff, ContextNames))
+	})
+
+	t.Run("Signatures", func(t *testing.T) {
+		annotated := Annotate(git, "", diff, ContextSignatures)
+		assert.Contains(t, annotated, "@@ -14,7 +14,7 @@ func (c *Client) Commit(message string) error\n")
+		assert.Contains(t, annotated, "@@ -6,3 +6,3 @@ type Client struct\n")
+	})
+
+	t.Run("None", func(t *testing.T) {
+		assert.Equal(t, diff, Annotate(git, "", diff, ContextNone))
+	})
+
+	t.Run("Unreadable", func(t *testing.T) {
+		assert.Equal(t, diff, Annotate(files{}, "", diff, ContextNames))
+	})
+}
+
+func TestEnclosing(t *testing.T) {
+	decls := []declaration{
+		{name: "A", start: 1, end: 5},
+		{name: "B", start: 7, end: 9},
+		{name: "C", start: 10, end: 12},
+		{name: "D", start: 13, end: 20},
+		{name: "E", start: 21, end: 30},
+	}
+	assert.Equal(t, "A", enclosing(decls, 2, 3, ContextNames))
+	assert.Equal(t, "", enclosing(decls, 6, 6, ContextNames))
+	assert.Equal(t, "A, B", enclosing(decls, 5, 7, ContextNames))
+	assert.Equal(t, "B, C, D, …", enclosing(decls, 8, 25, ContextNames))
+}
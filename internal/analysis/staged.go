@@ -0,0 +1,114 @@
+package analysis
+
+import (
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/rm-hull/git-commit-summary/internal/interfaces"
+)
+
+// FileReader reads the contents of a file at a revision, or when rev is
+// blank, as staged; as interfaces.GitClient does.
+type FileReader interface {
+	FileAt(rev, path string) (string, error)
+}
+
+// CachedReader reads each file once, however often it is asked for, so that
+// BreakingChanges and Annotate can share the reads of the same files.
+func CachedReader(git FileReader) FileReader {
+	return &cachedReader{git: git, files: map[[2]string]cachedFile{}}
+}
+
+type cachedFile struct {
+	src string
+	err error
+}
+
+type cachedReader struct {
+	git   FileReader
+	files map[[2]string]cachedFile
+}
+
+func (r *cachedReader) FileAt(rev, path string) (string, error) {
+	key := [2]string{rev, path}
+	file, ok := r.files[key]
+	if !ok {
+		file.src, file.err = r.git.FileAt(rev, path)
+		r.files[key] = file
+	}
+	return file.src, file.err
+}
+
+// BreakingChanges compares the exported API of the Go packages touched by the
+// staged changes, between HEAD and the index. The API of a package is taken
+// from its changed files only, which is enough to follow an identifier moved
+// between them. Internal packages, main packages and tests are not public API,
+// and so are skipped.
+func BreakingChanges(git FileReader, changes []interfaces.FileChange) ([]Change, error) {
+	oldAPIs := map[string]API{}
+	newAPIs := map[string]API{}
+	unparsed := map[string]bool{}
+
+	for _, change := range changes {
+		oldPath := change.Path
+		if change.OldPath != "" {
+			oldPath = change.OldPath
+		}
+
+		if change.Status != "A" && public(oldPath) {
+			src, err := git.FileAt("HEAD", oldPath)
+			if err != nil {
+				return nil, err
+			}
+			parse(oldAPIs, unparsed, oldPath, src)
+		}
+
+		if change.Status != "D" && public(change.Path) {
+			src, err := git.FileAt("", change.Path)
+			if err != nil {
+				return nil, err
+			}
+			parse(newAPIs, unparsed, change.Path, src)
+		}
+	}
+
+	var breaking []Change
+	for _, pkg := range sortedKeys(oldAPIs) {
+		if unparsed[pkg] {
+			continue
+		}
+		breaking = append(breaking, Compare(pkg, oldAPIs[pkg], newAPIs[pkg])...)
+	}
+	return breaking, nil
+}
+
+func public(filename string) bool {
+	if !strings.HasSuffix(filename, ".go") || strings.HasSuffix(filename, "_test.go") {
+		return false
+	}
+	return !slices.Contains(strings.Split(path.Dir(filename), "/"), "internal")
+}
+
+// parse adds the file's API to that of its package, or should it fail to
+// parse, as when part way through an edit, marks the package as unparsed so
+// that its identifiers are not all taken to be removed.
+func parse(apis map[string]API, unparsed map[string]bool, filename, src string) {
+	pkg := path.Dir(filename)
+	if apis[pkg] == nil {
+		apis[pkg] = API{}
+	}
+	if err := apis[pkg].ParseAPI(filename, []byte(src)); err != nil {
+		unparsed[pkg] = true
+	}
+}
+
+func sortedKeys(apis map[string]API) []string {
+	keys := make([]string, 0, len(apis))
+	for key := range apis {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,116 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rm-hull/git-commit-summary/internal/interfaces"
+)
+
+type files map[string]string
+
+func (f files) FileAt(rev, path string) (string, error) {
+	src, ok := f[rev+":"+path]
+	if !ok {
+		return "", errors.Newf("%s:%s not found", rev, path)
+	}
+	return src, nil
+}
+
+func TestBreakingChanges(t *testing.T) {
+	t.Run("MovedBetweenFiles", func(t *testing.T) {
+		git := files{
+			"HEAD:pkg/a.go": "package pkg\n\nfunc Moved() {}\n",
+			":pkg/a.go":     "package pkg\n",
+			"HEAD:pkg/b.go": "package pkg\n",
+			":pkg/b.go":     "package pkg\n\nfunc Moved() {}\n",
+		}
+		changes, err := BreakingChanges(git, []interfaces.FileChange{
+			{Status: "M", Path: "pkg/a.go"},
+			{Status: "M", Path: "pkg/b.go"},
+		})
+		assert.NoError(t, err)
+		assert.Empty(t, changes)
+	})
+
+	t.Run("RemovedAndRenamed", func(t *testing.T) {
+		git := files{
+			"HEAD:pkg/gone.go": "package pkg\n\nfunc Gone() {}\n",
+			"HEAD:old/x.go":    "package x\n\nfunc X(int) {}\n",
+			":new/x.go":        "package x\n\nfunc X(int) {}\n",
+			":pkg/new.go":      "package pkg\n\nfunc New() {}\n",
+		}
+		changes, err := BreakingChanges(git, []interfaces.FileChange{
+			{Status: "D", Path: "pkg/gone.go"},
+			{Status: "R", OldPath: "old/x.go", Path: "new/x.go"},
+			{Status: "A", Path: "pkg/new.go"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []Change{
+			{Package: "old", Name: "X", Old: "func(int)"},
+			{Package: "pkg", Name: "Gone", Old: "func()"},
+		}, changes)
+	})
+
+	t.Run("SkipsInternalMainTestsAndOthers", func(t *testing.T) {
+		changes, err := BreakingChanges(files{}, []interfaces.FileChange{
+			{Status: "D", Path: "internal/git/client.go"},
+			{Status: "D", Path: "pkg/internal/x.go"},
+			{Status: "D", Path: "pkg/x_test.go"},
+			{Status: "D", Path: "README.md"},
+		})
+		assert.NoError(t, err)
+		assert.Empty(t, changes)
+
+		git := files{"HEAD:main.go": "package main\n\nfunc Run() {}\n"}
+		changes, err = BreakingChanges(git, []interfaces.FileChange{{Status: "D", Path: "main.go"}})
+		assert.NoError(t, err)
+		assert.Empty(t, changes)
+	})
+
+	t.Run("SkipsUnparsedPackage", func(t *testing.T) {
+		git := files{
+			"HEAD:pkg/a.go": "package pkg\n\nfunc A() {}\n",
+			":pkg/a.go":     "package pkg\n\nfunc A( {}\n",
+		}
+		changes, err := BreakingChanges(git, []interfaces.FileChange{{Status: "M", Path: "pkg/a.go"}})
+		assert.NoError(t, err)
+		assert.Empty(t, changes)
+	})
+
+	t.Run("GitError", func(t *testing.T) {
+		_, err := BreakingChanges(files{}, []interfaces.FileChange{{Status: "M", Path: "pkg/a.go"}})
+		assert.EqualError(t, err, "HEAD:pkg/a.go not found")
+	})
+}
+
+type countingReader struct {
+	files
+	reads int
+}
+
+func (r *countingReader) FileAt(rev, path string) (string, error) {
+	r.reads++
+	return r.files.FileAt(rev, path)
+}
+
+func TestCachedReader(t *testing.T) {
+	git := &countingReader{files: files{":pkg/a.go": "package pkg\n\nfunc A() {}\n"}}
+	reader := CachedReader(git)
+
+	diff := "diff --git a/pkg/a.go b/pkg/a.go\n--- a/pkg/a.go\n+++ b/pkg/a.go\n@@ -3 +3 @@\n-func B() {}\n+func A() {}\n"
+	changes := []interfaces.FileChange{{Status: "A", Path: "pkg/a.go"}}
+
+	_, err := BreakingChanges(reader, changes)
+	assert.NoError(t, err)
+	assert.Contains(t, Annotate(reader, "", diff, ContextNames), "@@ -3 +3 @@ A")
+	assert.Equal(t, 1, git.reads, "the file is read once, for both")
+
+	_, err = reader.FileAt("HEAD", "pkg/a.go")
+	assert.Error(t, err)
+	_, err = reader.FileAt("HEAD", "pkg/a.go")
+	assert.Error(t, err)
+	assert.Equal(t, 2, git.reads, "a failed read is not repeated")
+}
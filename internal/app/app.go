@@ -2,12 +2,18 @@ package app
 
 import (
 	"context"
+	"fmt"
+	"io"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/cockroachdb/errors"
+	"github.com/rm-hull/git-commit-summary/internal/config"
 	"github.com/rm-hull/git-commit-summary/internal/git"
 	"github.com/rm-hull/git-commit-summary/internal/interfaces"
 	llmprovider "github.com/rm-hull/git-commit-summary/internal/llm_provider"
+	"github.com/rm-hull/git-commit-summary/internal/message"
+	"github.com/rm-hull/git-commit-summary/internal/session"
+	"github.com/rm-hull/git-commit-summary/internal/split"
 	"github.com/rm-hull/git-commit-summary/internal/ui"
 )
 
@@ -17,19 +23,35 @@ var _ interfaces.GitClient = (*git.Client)(nil)
 type App struct {
 	llmProvider llmprovider.Provider
 	git         interfaces.GitClient
-	prompt      string
+	cfg         *config.Config
 }
 
-func NewApp(provider llmprovider.Provider, git interfaces.GitClient, prompt string) *App {
+func NewApp(provider llmprovider.Provider, git interfaces.GitClient, cfg *config.Config) *App {
 	return &App{
 		llmProvider: provider,
 		git:         git,
-		prompt:      prompt,
+		cfg:         cfg,
 	}
 }
 
 func (app *App) Run(ctx context.Context, userMessage string) error {
-	model := ui.InitialModel(ctx, app.llmProvider, app.git, app.prompt, userMessage)
+	return app.run(ui.InitialModel(ctx, app.llmProvider, app.git, app.cfg, userMessage))
+}
+
+func (app *App) RunSquash(ctx context.Context, squashRange string, userMessage string) error {
+	return app.run(ui.InitialSquashModel(ctx, app.llmProvider, app.git, app.cfg, squashRange, userMessage))
+}
+
+func (app *App) run(model *ui.Model) error {
+	defer app.closeGit()
+
+	// an error here is reported by the UI, when it checks the work tree
+	gitDir, _ := app.git.GitDir()
+	sessions := session.New(app.cfg.SessionDir)
+	if saved, ok := sessions.Load(gitDir); ok && gitDir != "" {
+		model.RestoreMessage(saved.Message, saved.DiffHash)
+	}
+
 	p := tea.NewProgram(model)
 
 	finalModel, err := p.Run()
@@ -42,6 +64,42 @@ func (app *App) Run(ctx context.Context, userMessage string) error {
 		return errors.New("failed to cast model to *ui.Model")
 	}
 
+	if (m.Err() != nil || m.Action() == ui.Abort) && m.KeepMessage() && gitDir != "" {
+		// keep the message, as edited, to be offered again for the same changes
+		_ = sessions.Save(gitDir, session.Session{Message: m.LastMessage(), DiffHash: m.DiffHash()})
+	}
+
+	if m.Err() != nil {
+		return m.Err()
+	}
+
+	if m.Action() == ui.Abort {
+		return interfaces.ErrAborted
+	}
+
+	if m.Action() == ui.Commit && gitDir != "" {
+		_ = sessions.Delete(gitDir)
+	}
+
+	return nil
+}
+
+func (app *App) RunSplit(ctx context.Context) error {
+	defer app.closeGit()
+
+	model := ui.InitialSplitModel(ctx, app.llmProvider, app.git, app.cfg)
+	p := tea.NewProgram(model)
+
+	finalModel, err := p.Run()
+	if err != nil {
+		return err
+	}
+
+	m, ok := finalModel.(*ui.SplitModel)
+	if !ok {
+		return errors.New("failed to cast model to *ui.SplitModel")
+	}
+
 	if m.Err() != nil {
 		return m.Err()
 	}
@@ -51,11 +109,146 @@ func (app *App) Run(ctx context.Context, userMessage string) error {
 	}
 
 	if m.Action() == ui.Commit {
-		err = app.git.Commit(m.CommitMessage())
+		return app.commitSplit(m.Commits(), m.Changes())
+	}
+
+	return nil
+}
+
+// commitSplit creates the proposed commits one after another: the index is
+// reset, and then each commit's part of the original staged patch, its files
+// or some of their hunks, is applied to the index and committed. Should a
+// commit fail, the patches not yet committed are re-applied, so the
+// uncommitted changes are left staged.
+func (app *App) commitSplit(commits []split.Commit, changes []interfaces.FileChange) error {
+	var trailers []message.Trailer
+	if app.cfg.SignOff {
+		identity, err := app.git.Identity()
+		if err != nil {
+			return err
+		}
+		trailers = append(trailers, message.Trailer{Key: message.SignedOffBy, Value: identity})
+	}
+
+	var paths []string
+	filePatches := make(map[string]split.FilePatch, len(changes))
+	for _, change := range changes {
+		changePaths := split.PatchPaths([]string{change.Path}, changes)
+		patch, err := app.git.Patch(changePaths...)
+		if err != nil {
+			return err
+		}
+		filePatches[change.Path] = split.ParsePatch(patch)
+		paths = append(paths, changePaths...)
+	}
+
+	patches := make([]string, len(commits))
+	for i, commit := range commits {
+		patches[i] = commit.Patch(filePatches)
+	}
+
+	if err := app.git.Unstage(paths); err != nil {
+		return err
+	}
+
+	restore := func(from int, cause error) error {
+		// as much as can be is restored, even should one patch not apply
+		for _, patch := range patches[from:] {
+			if err := app.git.ApplyCached(patch); err != nil {
+				cause = errors.CombineErrors(cause, err)
+			}
+		}
+		return cause
+	}
+
+	for i, commit := range commits {
+		if err := app.git.ApplyCached(patches[i]); err != nil {
+			// nothing of this commit was applied, so it is restored too
+			return restore(i, err)
+		}
+		if err := app.git.Commit(message.AppendTrailers(commit.Message, trailers...)); err != nil {
+			// this commit's patch is already staged
+			return restore(i+1, err)
+		}
+	}
+
+	return nil
+}
+
+func (app *App) RunReword(ctx context.Context, revRange string, force bool) error {
+	defer app.closeGit()
+
+	if err := app.git.IsInWorkTree(); err != nil {
+		return err
+	}
+
+	commits, err := app.git.Commits(revRange)
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 {
+		return errors.Newf("no commits found in range %s", revRange)
+	}
+
+	if !force {
+		// if the oldest commit has not been pushed, then neither have its descendants
+		pushed, err := app.git.IsPushed(commits[0].Hash)
 		if err != nil {
 			return err
 		}
+		if pushed {
+			return errors.New("some commits in the range have already been pushed, use --force to reword them anyway")
+		}
+	}
+
+	// check the commits can be reworded before generating a message for each
+	hashes := make([]string, len(commits))
+	for i, commit := range commits {
+		hashes[i] = commit.Hash
+	}
+	if err := app.git.CheckReword(hashes); err != nil {
+		return err
+	}
+
+	model := ui.InitialRewordModel(ctx, app.llmProvider, app.git, app.cfg, commits)
+	p := tea.NewProgram(model)
+
+	finalModel, err := p.Run()
+	if err != nil {
+		return err
+	}
+
+	m, ok := finalModel.(*ui.RewordModel)
+	if !ok {
+		return errors.New("failed to cast model to *ui.RewordModel")
+	}
+
+	if m.Err() != nil {
+		return m.Err()
 	}
 
+	if m.Action() == ui.Abort {
+		return interfaces.ErrAborted
+	}
+
+	messages := m.Messages()
+	if m.Action() != ui.Commit || len(messages) == 0 {
+		return nil
+	}
+
+	backupRef, err := app.git.Reword(messages)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Reworded %d commit(s), the previous history is kept in %s\n", len(messages), backupRef)
 	return nil
 }
+
+// closeGit releases what the git client holds on to, such as a temporary
+// index, once it is no longer needed.
+func (app *App) closeGit() {
+	if closer, ok := app.git.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
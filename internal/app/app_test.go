@@ -0,0 +1,122 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rm-hull/git-commit-summary/internal/config"
+	"github.com/rm-hull/git-commit-summary/internal/interfaces"
+	"github.com/rm-hull/git-commit-summary/internal/split"
+)
+
+// fakeGitClient records the patches applied to the index and the commits
+// made, failing to apply the given patch the first time, or to make the given
+// commit.
+type fakeGitClient struct {
+	interfaces.GitClient
+	failApply  string
+	failCommit string
+	applied    []string
+	committed  []string
+}
+
+func (f *fakeGitClient) Patch(paths ...string) (string, error) {
+	return "patch of " + paths[0] + "\n", nil
+}
+
+func (f *fakeGitClient) Unstage(paths []string) error {
+	return nil
+}
+
+func (f *fakeGitClient) ApplyCached(patch string) error {
+	if patch == f.failApply {
+		f.failApply = ""
+		return errors.New("git apply failed")
+	}
+	f.applied = append(f.applied, patch)
+	return nil
+}
+
+func (f *fakeGitClient) Commit(message string) error {
+	if message == f.failCommit {
+		return errors.New("hook failed")
+	}
+	f.committed = append(f.committed, message)
+	return nil
+}
+
+func TestCommitSplit(t *testing.T) {
+	changes := []interfaces.FileChange{
+		{Status: "M", Path: "a.go"},
+		{Status: "M", Path: "b.go"},
+		{Status: "M", Path: "c.go"},
+	}
+	commits := []split.Commit{
+		{Message: "feat: a", Files: []string{"a.go"}},
+		{Message: "feat: b", Files: []string{"b.go"}},
+		{Message: "feat: c", Files: []string{"c.go"}},
+	}
+
+	t.Run("Commits each in turn", func(t *testing.T) {
+		git := &fakeGitClient{}
+		app := NewApp(nil, git, &config.Config{})
+
+		assert.NoError(t, app.commitSplit(commits, changes))
+		assert.Equal(t, []string{"patch of a.go\n", "patch of b.go\n", "patch of c.go\n"}, git.applied)
+		assert.Equal(t, []string{"feat: a", "feat: b", "feat: c"}, git.committed)
+	})
+
+	t.Run("Restores the patch that failed to apply", func(t *testing.T) {
+		git := &fakeGitClient{failApply: "patch of b.go\n"}
+		app := NewApp(nil, git, &config.Config{})
+
+		err := app.commitSplit(commits, changes)
+		assert.ErrorContains(t, err, "git apply failed")
+		// b.go was not staged, so is restored along with c.go
+		assert.Equal(t, []string{"patch of a.go\n", "patch of b.go\n", "patch of c.go\n"}, git.applied)
+		assert.Equal(t, []string{"feat: a"}, git.committed)
+	})
+
+	t.Run("Leaves the commit that failed staged", func(t *testing.T) {
+		git := &fakeGitClient{failCommit: "feat: b"}
+		app := NewApp(nil, git, &config.Config{})
+
+		err := app.commitSplit(commits, changes)
+		assert.ErrorContains(t, err, "hook failed")
+		assert.Equal(t, []string{"patch of a.go\n", "patch of b.go\n", "patch of c.go\n"}, git.applied)
+		assert.Equal(t, []string{"feat: a"}, git.committed)
+	})
+}
+
+// rewordGitClient lists the commits of a range, which cannot be reworded.
+type rewordGitClient struct {
+	interfaces.GitClient
+	checked []string
+}
+
+func (f *rewordGitClient) IsInWorkTree() error {
+	return nil
+}
+
+func (f *rewordGitClient) Commits(revRange string) ([]interfaces.Commit, error) {
+	return []interfaces.Commit{{Hash: "aaa"}, {Hash: "bbb"}}, nil
+}
+
+func (f *rewordGitClient) CheckReword(hashes []string) error {
+	f.checked = hashes
+	return errors.New("commits to reword must be ancestors of HEAD")
+}
+
+func TestRunReword(t *testing.T) {
+	git := &rewordGitClient{}
+	app := NewApp(nil, git, &config.Config{})
+
+	// no provider is needed, as the range is checked before any message is generated
+	err := app.RunReword(context.Background(), "main..feature", true)
+
+	assert.EqualError(t, err, "commits to reword must be ancestors of HEAD")
+	assert.Equal(t, []string{"aaa", "bbb"}, git.checked)
+}
@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Cache is an on-disk store of LLM responses, so that re-running the tool on
+// the same staged changes does not pay for another model call. Entries expire
+// after the TTL, and the oldest entries are evicted once the total size
+// exceeds the maximum.
+type Cache struct {
+	dir     string
+	ttl     time.Duration
+	maxSize int64
+}
+
+func New(dir string, ttl time.Duration, maxSize int64) *Cache {
+	return &Cache{
+		dir:     dir,
+		ttl:     ttl,
+		maxSize: maxSize,
+	}
+}
+
+// Key identifies a response by the provider, model and the rendered prompt.
+func Key(provider, model, prompt string) string {
+	hash := sha256.New()
+	for _, part := range []string{provider, model, prompt} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (c *Cache) Get(key string) (string, bool) {
+	path := filepath.Join(c.dir, key)
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", false
+	}
+
+	if c.expired(info) {
+		_ = os.Remove(path)
+		return "", false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+func (c *Cache) Put(key, value string) error {
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return errors.Wrap(err, "failed to create cache directory")
+	}
+
+	// write to a temporary file first, so that a concurrent Get never sees a partial entry
+	tmpfile, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return errors.Wrap(err, "failed to write cache entry")
+	}
+	defer func() {
+		_ = os.Remove(tmpfile.Name()) // clean up, if not renamed
+	}()
+
+	if _, err := tmpfile.WriteString(value); err != nil {
+		_ = tmpfile.Close()
+		return errors.Wrap(err, "failed to write cache entry")
+	}
+	if err := tmpfile.Close(); err != nil {
+		return errors.Wrap(err, "failed to write cache entry")
+	}
+	if err := os.Rename(tmpfile.Name(), filepath.Join(c.dir, key)); err != nil {
+		return errors.Wrap(err, "failed to write cache entry")
+	}
+
+	return c.prune()
+}
+
+// Clear removes every cached response.
+func (c *Cache) Clear() error {
+	if err := os.RemoveAll(c.dir); err != nil {
+		return errors.Wrap(err, "failed to clear cache")
+	}
+	return nil
+}
+
+func (c *Cache) prune() error {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return errors.Wrap(err, "failed to read cache directory")
+	}
+
+	var infos []os.FileInfo
+	var totalSize int64
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		if c.expired(info) {
+			_ = os.Remove(filepath.Join(c.dir, info.Name()))
+			continue
+		}
+
+		infos = append(infos, info)
+		totalSize += info.Size()
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ModTime().Before(infos[j].ModTime())
+	})
+
+	for _, info := range infos {
+		if totalSize <= c.maxSize {
+			break
+		}
+		_ = os.Remove(filepath.Join(c.dir, info.Name()))
+		totalSize -= info.Size()
+	}
+
+	return nil
+}
+
+func (c *Cache) expired(info os.FileInfo) bool {
+	return c.ttl > 0 && time.Since(info.ModTime()) > c.ttl
+}
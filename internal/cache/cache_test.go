@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKey(t *testing.T) {
+	key := Key("google", "gemini-pro", "prompt")
+	assert.Len(t, key, 64)
+	assert.Equal(t, key, Key("google", "gemini-pro", "prompt"))
+	assert.NotEqual(t, key, Key("openai", "gemini-pro", "prompt"))
+	assert.NotEqual(t, key, Key("google", "gemini-flash", "prompt"))
+	assert.NotEqual(t, key, Key("google", "gemini-pro", "other prompt"))
+	assert.NotEqual(t, Key("ab", "c", ""), Key("a", "bc", ""))
+}
+
+func TestCache(t *testing.T) {
+	t.Run("Put and Get", func(t *testing.T) {
+		c := New(t.TempDir(), time.Hour, 1024)
+
+		_, ok := c.Get("missing")
+		assert.False(t, ok)
+
+		assert.NoError(t, c.Put("key", "feat: cached response"))
+		value, ok := c.Get("key")
+		assert.True(t, ok)
+		assert.Equal(t, "feat: cached response", value)
+	})
+
+	t.Run("Expired entries are ignored", func(t *testing.T) {
+		dir := t.TempDir()
+		c := New(dir, time.Hour, 1024)
+		assert.NoError(t, c.Put("key", "stale"))
+
+		old := time.Now().Add(-2 * time.Hour)
+		assert.NoError(t, os.Chtimes(filepath.Join(dir, "key"), old, old))
+
+		_, ok := c.Get("key")
+		assert.False(t, ok)
+		assert.NoFileExists(t, filepath.Join(dir, "key"))
+	})
+
+	t.Run("Oldest entries are evicted when too big", func(t *testing.T) {
+		dir := t.TempDir()
+		c := New(dir, time.Hour, 25)
+
+		for i, key := range []string{"first", "second", "third"} {
+			assert.NoError(t, c.Put(key, strings.Repeat("x", 10)))
+			at := time.Now().Add(time.Duration(i-3) * time.Minute)
+			assert.NoError(t, os.Chtimes(filepath.Join(dir, key), at, at))
+		}
+		assert.NoError(t, c.prune())
+
+		_, ok := c.Get("first")
+		assert.False(t, ok)
+		_, ok = c.Get("second")
+		assert.True(t, ok)
+		_, ok = c.Get("third")
+		assert.True(t, ok)
+	})
+
+	t.Run("Clear", func(t *testing.T) {
+		c := New(filepath.Join(t.TempDir(), "responses"), time.Hour, 1024)
+		assert.NoError(t, c.Put("key", "value"))
+		assert.NoError(t, c.Clear())
+
+		_, ok := c.Get("key")
+		assert.False(t, ok)
+	})
+}
@@ -3,14 +3,29 @@ package config
 import (
 	_ "embed"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/adrg/xdg"
+	"github.com/cockroachdb/errors"
 	"github.com/joho/godotenv"
+	"github.com/rm-hull/git-commit-summary/internal/analysis"
+	"github.com/rm-hull/git-commit-summary/internal/keys"
+	"github.com/rm-hull/git-commit-summary/internal/message"
+	"github.com/rm-hull/git-commit-summary/internal/theme"
 )
 
 //go:embed prompt.md
 var prompt string
 
+//go:embed split_prompt.md
+var splitPrompt string
+
+//go:embed squash_prompt.md
+var squashPrompt string
+
 type GeminiConfig struct {
 	APIKey string
 	Model  string
@@ -22,11 +37,35 @@ type OpenAIConfig struct {
 	BaseURL string
 }
 
+type CacheConfig struct {
+	Dir      string
+	TTL      time.Duration
+	MaxSize  int64
+	Disabled bool // set from the --no-cache command-line flag
+}
+
 type Config struct {
-	LLMProvider string
-	Prompt      string
-	Gemini      GeminiConfig
-	OpenAI      OpenAIConfig
+	LLMProvider  string
+	Prompt       string
+	SplitPrompt  string
+	SquashPrompt string
+	Gemini       GeminiConfig
+	OpenAI       OpenAIConfig
+	Cache        CacheConfig
+	SessionDir   string
+	Style        message.Style
+	Layout       message.Layout
+	Language     string
+	Format       string // markdown or plain
+	Structured   bool   // ask for the message in parts, where the provider supports it
+	DiffContext  string // none, names or signatures of the changed functions and types
+	ScopeMap     message.ScopeMap
+	Keys         keys.Map
+	Theme        theme.Config
+
+	// Set from command-line flags only
+	SelectFiles bool
+	SignOff     bool
 }
 
 func Load() (*Config, error) {
@@ -41,8 +80,13 @@ func Load() (*Config, error) {
 	_ = godotenv.Overload(".env")
 
 	cfg := &Config{
-		LLMProvider: os.Getenv("LLM_PROVIDER"),
-		Prompt:      prompt,
+		LLMProvider:  os.Getenv("LLM_PROVIDER"),
+		Language:     os.Getenv("COMMIT_LANGUAGE"),
+		Format:       os.Getenv("MESSAGE_FORMAT"),
+		DiffContext:  os.Getenv("DIFF_CONTEXT"),
+		Prompt:       prompt,
+		SplitPrompt:  splitPrompt,
+		SquashPrompt: squashPrompt,
 		Gemini: GeminiConfig{
 			APIKey: os.Getenv("GEMINI_API_KEY"),
 			Model:  os.Getenv("GEMINI_MODEL"),
@@ -66,5 +110,100 @@ func Load() (*Config, error) {
 		cfg.OpenAI.Model = "gpt-4o"
 	}
 
+	cfg.Style, err = message.NewStyle(os.Getenv("COMMIT_STYLE"), os.Getenv("COMMIT_TEMPLATE"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid COMMIT_STYLE")
+	}
+
+	if err := message.ValidateFormat(cfg.Format); err != nil {
+		return nil, errors.Wrap(err, "invalid MESSAGE_FORMAT")
+	}
+	if cfg.Format == "" {
+		cfg.Format = message.FormatMarkdown
+	}
+
+	cfg.ScopeMap, err = message.ParseScopeMap(splitList(os.Getenv("SCOPE_MAP"))...)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid SCOPE_MAP")
+	}
+
+	cfg.Keys, err = keys.Parse(splitList(os.Getenv("KEY_BINDINGS"))...)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid KEY_BINDINGS")
+	}
+
+	cfg.Theme, err = theme.Parse(os.Getenv("THEME"), splitList(os.Getenv("THEME_COLORS"))...)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid THEME")
+	}
+	// see https://no-color.org
+	cfg.Theme.NoColor = os.Getenv("NO_COLOR") != ""
+
+	switch cfg.DiffContext {
+	case "":
+		cfg.DiffContext = analysis.ContextNames
+	case analysis.ContextNone, analysis.ContextNames, analysis.ContextSignatures:
+	default:
+		return nil, errors.Newf("invalid DIFF_CONTEXT: %s, expected one of: %s", cfg.DiffContext, strings.Join(analysis.Contexts, ", "))
+	}
+
+	if structured := os.Getenv("STRUCTURED_OUTPUT"); structured != "" {
+		cfg.Structured, err = strconv.ParseBool(structured)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid STRUCTURED_OUTPUT: %s", structured)
+		}
+	}
+
+	if enabled := os.Getenv("WRAP_ENABLED"); enabled != "" {
+		wrap, err := strconv.ParseBool(enabled)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid WRAP_ENABLED: %s", enabled)
+		}
+		cfg.Layout.Disabled = !wrap
+	}
+
+	cfg.Layout.Width = message.DefaultWidth
+	if width := os.Getenv("WRAP_WIDTH"); width != "" {
+		cfg.Layout.Width, err = strconv.Atoi(width)
+		if err != nil || cfg.Layout.Width <= 0 {
+			return nil, errors.Newf("invalid WRAP_WIDTH: %s", width)
+		}
+	}
+
+	cfg.Layout.SubjectWidth = message.DefaultSubjectWidth
+	if width := os.Getenv("SUBJECT_WIDTH"); width != "" {
+		cfg.Layout.SubjectWidth, err = strconv.Atoi(width)
+		if err != nil || cfg.Layout.SubjectWidth <= 0 {
+			return nil, errors.Newf("invalid SUBJECT_WIDTH: %s", width)
+		}
+	}
+
+	cfg.SessionDir = filepath.Join(xdg.StateHome, "git-commit-summary", "sessions")
+	cfg.Cache.Dir = filepath.Join(xdg.CacheHome, "git-commit-summary", "responses")
+
+	cfg.Cache.TTL = 7 * 24 * time.Hour
+	if ttl := os.Getenv("CACHE_TTL"); ttl != "" {
+		cfg.Cache.TTL, err = time.ParseDuration(ttl)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid CACHE_TTL: %s", ttl)
+		}
+	}
+
+	cfg.Cache.MaxSize = 10 * 1024 * 1024
+	if maxSize := os.Getenv("CACHE_MAX_SIZE"); maxSize != "" {
+		cfg.Cache.MaxSize, err = strconv.ParseInt(maxSize, 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid CACHE_MAX_SIZE: %s", maxSize)
+		}
+	}
+
 	return cfg, nil
 }
+
+// splitList splits the entries of a setting such as SCOPE_MAP, KEY_BINDINGS
+// or THEME_COLORS, which are separated by commas or whitespace.
+func splitList(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+}
@@ -2,8 +2,13 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/rm-hull/git-commit-summary/internal/message"
+	prompts "github.com/rm-hull/git-commit-summary/internal/prompt"
+	"github.com/rm-hull/git-commit-summary/internal/theme"
 )
 
 func TestLoad(t *testing.T) {
@@ -11,6 +16,21 @@ func TestLoad(t *testing.T) {
 		t.Setenv("LLM_PROVIDER", "")
 		t.Setenv("GEMINI_MODEL", "")
 		t.Setenv("OPENAI_MODEL", "")
+		t.Setenv("CACHE_TTL", "")
+		t.Setenv("CACHE_MAX_SIZE", "")
+		t.Setenv("COMMIT_STYLE", "")
+		t.Setenv("COMMIT_LANGUAGE", "")
+		t.Setenv("WRAP_ENABLED", "")
+		t.Setenv("WRAP_WIDTH", "")
+		t.Setenv("SUBJECT_WIDTH", "")
+		t.Setenv("MESSAGE_FORMAT", "")
+		t.Setenv("STRUCTURED_OUTPUT", "")
+		t.Setenv("DIFF_CONTEXT", "")
+		t.Setenv("SCOPE_MAP", "")
+		t.Setenv("KEY_BINDINGS", "")
+		t.Setenv("THEME", "")
+		t.Setenv("THEME_COLORS", "")
+		t.Setenv("NO_COLOR", "")
 
 		cfg, err := Load()
 		assert.NoError(t, err)
@@ -18,17 +38,138 @@ func TestLoad(t *testing.T) {
 		assert.Equal(t, "gemini-2.5-flash-preview-09-2025", cfg.Gemini.Model)
 		assert.Equal(t, "gpt-4o", cfg.OpenAI.Model)
 		assert.NotEmpty(t, cfg.Prompt)
+		assert.NotEmpty(t, cfg.SplitPrompt)
+		assert.NotEmpty(t, cfg.SquashPrompt)
+		assert.NotEmpty(t, cfg.Cache.Dir)
+		assert.NotEmpty(t, cfg.SessionDir)
+		assert.Equal(t, 7*24*time.Hour, cfg.Cache.TTL)
+		assert.Equal(t, theme.Auto, cfg.Theme.Name)
+		assert.False(t, cfg.Theme.NoColor)
+		assert.Equal(t, int64(10*1024*1024), cfg.Cache.MaxSize)
+		assert.False(t, cfg.Cache.Disabled)
+		assert.Equal(t, "conventional", cfg.Style.Name)
+		assert.Empty(t, cfg.Language)
+		assert.Equal(t, message.Layout{Width: 72, SubjectWidth: 50}, cfg.Layout)
+		assert.Equal(t, "markdown", cfg.Format)
+		assert.False(t, cfg.Structured)
+		assert.Equal(t, "names", cfg.DiffContext)
+		assert.Empty(t, cfg.ScopeMap)
 	})
 
 	t.Run("WithEnvironmentVariables", func(t *testing.T) {
 		t.Setenv("LLM_PROVIDER", "openai")
 		t.Setenv("GEMINI_MODEL", "gemini-pro")
 		t.Setenv("OPENAI_MODEL", "gpt-3.5-turbo")
+		t.Setenv("CACHE_TTL", "1h")
+		t.Setenv("CACHE_MAX_SIZE", "2048")
+		t.Setenv("COMMIT_STYLE", "custom")
+		t.Setenv("COMMIT_TEMPLATE", "[{{.Scope}}] {{.Description}}")
+		t.Setenv("COMMIT_LANGUAGE", "German")
+		t.Setenv("WRAP_ENABLED", "false")
+		t.Setenv("WRAP_WIDTH", "80")
+		t.Setenv("SUBJECT_WIDTH", "50")
+		t.Setenv("MESSAGE_FORMAT", "plain")
+		t.Setenv("STRUCTURED_OUTPUT", "true")
+		t.Setenv("DIFF_CONTEXT", "signatures")
+		t.Setenv("SCOPE_MAP", "services/api/**=api, web/**=web")
+		t.Setenv("KEY_BINDINGS", "commit=ctrl+s regenerate=ctrl+e")
+		t.Setenv("THEME", "light")
+		t.Setenv("THEME_COLORS", "magenta=#FF00FF,\nyellow=3")
+		t.Setenv("NO_COLOR", "1")
 
 		cfg, err := Load()
 		assert.NoError(t, err)
 		assert.Equal(t, "openai", cfg.LLMProvider)
 		assert.Equal(t, "gemini-pro", cfg.Gemini.Model)
 		assert.Equal(t, "gpt-3.5-turbo", cfg.OpenAI.Model)
+		assert.Equal(t, time.Hour, cfg.Cache.TTL)
+		assert.Equal(t, int64(2048), cfg.Cache.MaxSize)
+		assert.Equal(t, "custom", cfg.Style.Name)
+		assert.Equal(t, "[{{.Scope}}] {{.Description}}", cfg.Style.Template)
+		assert.Equal(t, "German", cfg.Language)
+		assert.Equal(t, message.Layout{Width: 80, SubjectWidth: 50, Disabled: true}, cfg.Layout)
+		assert.Equal(t, "plain", cfg.Format)
+		assert.True(t, cfg.Structured)
+		assert.Equal(t, "signatures", cfg.DiffContext)
+		assert.Equal(t, message.ScopeMap{{Pattern: "services/api/**", Scope: "api"}, {Pattern: "web/**", Scope: "web"}}, cfg.ScopeMap)
+		assert.Equal(t, []string{"ctrl+s"}, cfg.Keys.Commit.Keys())
+		assert.Equal(t, []string{"ctrl+e"}, cfg.Keys.Regenerate.Keys())
+		assert.Equal(t, theme.Config{Name: "light", Colors: map[string]string{"magenta": "#FF00FF", "yellow": "3"}, NoColor: true}, cfg.Theme)
+	})
+
+	t.Run("InvalidCacheTTL", func(t *testing.T) {
+		t.Setenv("CACHE_TTL", "forever")
+
+		_, err := Load()
+		assert.ErrorContains(t, err, "invalid CACHE_TTL")
+	})
+
+	t.Run("InvalidWrapWidth", func(t *testing.T) {
+		t.Setenv("WRAP_WIDTH", "0")
+
+		_, err := Load()
+		assert.ErrorContains(t, err, "invalid WRAP_WIDTH")
+	})
+
+	t.Run("InvalidCommitStyle", func(t *testing.T) {
+		t.Setenv("COMMIT_STYLE", "fancy")
+
+		_, err := Load()
+		assert.ErrorContains(t, err, "invalid COMMIT_STYLE")
+	})
+
+	t.Run("InvalidScopeMap", func(t *testing.T) {
+		t.Setenv("SCOPE_MAP", "api")
+
+		_, err := Load()
+		assert.ErrorContains(t, err, "invalid SCOPE_MAP")
+	})
+
+	t.Run("InvalidKeyBindings", func(t *testing.T) {
+		t.Setenv("KEY_BINDINGS", "commit=ctrl+r")
+
+		_, err := Load()
+		assert.ErrorContains(t, err, "invalid KEY_BINDINGS: ctrl+r is bound to both commit and regenerate")
+	})
+
+	t.Run("InvalidTheme", func(t *testing.T) {
+		t.Setenv("THEME", "neon")
+
+		_, err := Load()
+		assert.ErrorContains(t, err, "invalid THEME")
 	})
+
+	t.Run("InvalidDiffContext", func(t *testing.T) {
+		t.Setenv("DIFF_CONTEXT", "everything")
+
+		_, err := Load()
+		assert.ErrorContains(t, err, "invalid DIFF_CONTEXT")
+	})
+
+	t.Run("InvalidMessageFormat", func(t *testing.T) {
+		t.Setenv("MESSAGE_FORMAT", "html")
+
+		_, err := Load()
+		assert.ErrorContains(t, err, "invalid MESSAGE_FORMAT")
+	})
+}
+
+func TestSplitList(t *testing.T) {
+	assert.Empty(t, splitList(""))
+	assert.Equal(t, []string{"a=1", "b=2", "c=3", "d=4"}, splitList(" a=1, b=2,,c=3\n\td=4 "))
+}
+
+func TestPrompts(t *testing.T) {
+	for name, template := range map[string]string{"prompt": prompt, "squash": squashPrompt} {
+		t.Run(name, func(t *testing.T) {
+			out, err := prompts.Render(template, prompts.Data{Scope: "ui"})
+			assert.NoError(t, err)
+			assert.Contains(t, out, "points.\n-   The changed files suggest `ui` as the scope, should the subject line have one.\n-   Wrap")
+
+			// no blank line is left in the list without a scope
+			out, err = prompts.Render(template, prompts.Data{})
+			assert.NoError(t, err)
+			assert.Contains(t, out, "points.\n-   Wrap")
+		})
+	}
 }
@@ -1,18 +1,44 @@
 package git
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/cockroachdb/errors"
+	"github.com/rm-hull/git-commit-summary/internal/interfaces"
 )
 
-type Client struct{}
+// Scope determines which changes are considered to be part of the commit.
+type Scope int
 
-func NewClient() *Client {
-	return &Client{}
+const (
+	// StagedOnly considers only the changes already in the index.
+	StagedOnly Scope = iota
+	// Tracked additionally considers modified and deleted tracked files, like `git commit --all`.
+	Tracked
+	// TrackedAndUntracked additionally considers untracked files that are not ignored.
+	TrackedAndUntracked
+)
+
+type Client struct {
+	scope Scope
+
+	// indexFile is a copy of the index with the pending changes added to it,
+	// built on first use and kept until the index is next changed
+	mu        sync.Mutex
+	indexFile string
+}
+
+func NewClient(scope Scope) *Client {
+	return &Client{scope: scope}
 }
 
 func (c *Client) IsInWorkTree() error {
@@ -35,52 +61,332 @@ func (c *Client) IsInWorkTree() error {
 	return nil
 }
 
-func (c *Client) StagedFiles() ([]string, error) {
+func (c *Client) GitDir() (string, error) {
+	result, err := exec.Command("git", "rev-parse", "--absolute-git-dir").Output()
+	if err != nil {
+		return "", errors.Wrap(err, "locating git directory failed")
+	}
+	return strings.TrimSpace(string(result)), nil
+}
+
+// ConfigValue returns the value of the given git config key, or an empty
+// string if it is not set.
+func (c *Client) ConfigValue(key string) (string, error) {
+	result, err := exec.Command("git", "config", "--get", key).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return "", nil
+		}
+		return "", errors.Wrapf(err, "reading git config %s failed", key)
+	}
+	return strings.TrimSpace(string(result)), nil
+}
+
+// Identity returns the committer's name and email, as `Name <email>`.
+func (c *Client) Identity() (string, error) {
+	result, err := exec.Command("git", "var", "GIT_COMMITTER_IDENT").Output()
+	if err != nil {
+		return "", errors.Wrap(err, "determining committer identity failed")
+	}
+	ident := strings.TrimSpace(string(result))
+	if end := strings.LastIndex(ident, ">"); end >= 0 {
+		ident = ident[:end+1]
+	}
+	return ident, nil
+}
+
+// RecentAuthors returns up to limit distinct authors of the most recent
+// commits, most recent first, as `Name <email>` with .mailmap applied.
+func (c *Client) RecentAuthors(limit int) ([]string, error) {
+	result, err := exec.Command("git", "log", "--no-merges", "--max-count=500", "--format=%aN <%aE>").Output()
+	if err != nil {
+		if exec.Command("git", "rev-parse", "--verify", "--quiet", "HEAD").Run() != nil {
+			// nothing has been committed yet, so there are no authors
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "listing recent authors failed")
+	}
+
+	var authors []string
+	for _, author := range strings.Split(strings.TrimSpace(string(result)), "\n") {
+		if author != "" && !slices.Contains(authors, author) {
+			authors = append(authors, author)
+			if len(authors) == limit {
+				break
+			}
+		}
+	}
+	return authors, nil
+}
+
+// StagedChanges describes each staged file, optionally restricted to the
+// given paths (relative to the repository root).
+func (c *Client) StagedChanges(paths ...string) ([]interfaces.FileChange, error) {
+	return c.changes(true, []string{"--staged"}, paths)
+}
+
+// ChangesBetween describes each file changed between two revisions. An empty
+// from revision compares against the empty tree.
+func (c *Client) ChangesBetween(from, to string) ([]interfaces.FileChange, error) {
+	from, err := revOrEmptyTree(from)
+	if err != nil {
+		return nil, err
+	}
+	return c.changes(false, []string{from, to}, nil)
+}
+
+func (c *Client) changes(staged bool, revs []string, paths []string) ([]interfaces.FileChange, error) {
+	pathspecs := append([]string{"--"}, topLevelPathspecs(paths)...)
+
+	args := append([]string{"diff", "--find-renames", "--name-status", "-z"}, revs...)
+	nameStatus, err := c.output(staged, false, append(args, pathspecs...)...)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing changes failed")
+	}
+
+	args = append([]string{"diff", "--find-renames", "--numstat", "-z"}, revs...)
+	numstat, err := c.output(staged, false, append(args, pathspecs...)...)
+	if err != nil {
+		return nil, errors.Wrap(err, "counting changes failed")
+	}
+
+	changes, err := parseNameStatus(string(nameStatus))
+	if err != nil {
+		return nil, err
+	}
+	if err := mergeNumstat(changes, string(numstat)); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// diffExcludes are pathspecs for generated files that would only add noise
+// (and tokens) to the diff.
+var diffExcludes = []string{
+	":(exclude)*-lock.*", // package-lock.json, pnpm-lock.yaml, etc.
+	":(exclude)*.lock",   // yarn.lock, poetry.lock, Cargo.lock, etc.
+	":(exclude)**/build/**",
+	":(exclude)**/dist/**",
+	":(exclude)**/target/**",
+	":(exclude)**/out/**",
+	":(exclude)go.sum",
+}
+
+// Diff returns the staged diff, optionally restricted to the given paths
+// (relative to the repository root).
+func (c *Client) Diff(paths ...string) (string, error) {
+	return c.diff(true, []string{"--staged"}, paths)
+}
+
+// DiffBetween returns the diff between two revisions. An empty from revision
+// compares against the empty tree.
+func (c *Client) DiffBetween(from, to string) (string, error) {
+	from, err := revOrEmptyTree(from)
+	if err != nil {
+		return "", err
+	}
+	return c.diff(false, []string{from, to}, nil)
+}
+
+func (c *Client) diff(staged bool, revs []string, paths []string) (string, error) {
+	args := append([]string{
+		"--no-pager",
+		"diff",
+		"--no-ext-diff",
+		"--no-textconv",
+		"--diff-filter=ACMRTUXBD",
+		"--src-prefix=a/", // whatever diff.mnemonicPrefix or diff.noprefix say
+		"--dst-prefix=b/",
+	}, revs...)
+	args = append(args, "--") // separates options from pathspecs
+	if len(paths) == 0 {
+		args = append(args, ".") // include everything under the repo root
+	} else {
+		args = append(args, topLevelPathspecs(paths)...)
+	}
+	args = append(args, diffExcludes...)
+
+	result, err := c.output(staged, true, args...)
+	if err != nil {
+		return "", errors.Wrap(err, "git diff failed")
+	}
+	return string(result), nil
+}
+
+// Commits lists the commits in the given range, oldest first. A single
+// revision is taken to mean everything since that revision, up to HEAD.
+func (c *Client) Commits(revRange string) ([]interfaces.Commit, error) {
+	if !strings.Contains(revRange, "..") {
+		revRange += "..HEAD"
+	}
+
 	result, err := exec.Command(
 		"git",
-		"diff",
-		"--staged",
-		"--name-only",
+		"log",
+		"--reverse",
+		"--format=%H%x1f%P%x1f%B%x00",
+		revRange,
+		"--",
 	).CombinedOutput()
+	if err != nil {
+		return nil, errors.Wrapf(err, "git log failed: %s", strings.TrimSpace(string(result)))
+	}
 
+	return parseLog(string(result)), nil
+}
+
+// PendingFiles lists the files, relative to the repository root, that are not
+// yet staged but will be staged on commit because of the client's scope.
+func (c *Client) PendingFiles() ([]string, error) {
+	if c.scope == StagedOnly {
+		return nil, nil
+	}
+
+	modified, err := exec.Command("git", "diff", "--name-only", "-z").Output()
 	if err != nil {
-		return nil, errors.Wrap(err, "listing staged files failed")
+		return nil, errors.Wrap(err, "listing unstaged files failed")
+	}
+	files := splitNul(string(modified))
+
+	if c.scope == TrackedAndUntracked {
+		untracked, err := exec.Command(
+			"git",
+			"ls-files",
+			"--others",
+			"--exclude-standard",
+			"--full-name",
+			"-z",
+			"--",
+			":/",
+		).Output()
+		if err != nil {
+			return nil, errors.Wrap(err, "listing untracked files failed")
+		}
+		files = append(files, splitNul(string(untracked))...)
 	}
 
-	trimmed := strings.TrimSpace(string(result))
-	if trimmed == "" {
+	sort.Strings(files)
+	return files, nil
+}
+
+// UnstagedFiles lists which of the given paths, relative to the repository
+// root, have changes in the working tree that are not staged.
+func (c *Client) UnstagedFiles(paths []string) ([]string, error) {
+	if len(paths) == 0 {
 		return nil, nil
 	}
-	return strings.Split(trimmed, "\n"), nil
+
+	args := append([]string{"diff", "--name-only", "-z", "--"}, topLevelPathspecs(paths)...)
+	result, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "listing unstaged files failed")
+	}
+	return splitNul(string(result)), nil
 }
 
-func (c *Client) Diff() (string, error) {
-	result, err := exec.Command(
-		"git",
+// Stage adds the given paths, relative to the repository root, to the index.
+// Deleted files are recorded as removals.
+func (c *Client) Stage(paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	defer c.resetPendingIndex()
+
+	args := append([]string{"add", "--all", "--"}, topLevelPathspecs(paths)...)
+	result, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "git add failed: %s", strings.TrimSpace(string(result)))
+	}
+	return nil
+}
+
+// Unstage removes the given paths, relative to the repository root, from the
+// index, leaving the working tree untouched.
+func (c *Client) Unstage(paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	defer c.resetPendingIndex()
+
+	args := append([]string{"reset", "--quiet", "--"}, topLevelPathspecs(paths)...)
+	if err := exec.Command("git", "rev-parse", "--verify", "--quiet", "HEAD").Run(); err != nil {
+		// nothing has been committed yet, so there is nothing to reset to
+		args = append([]string{"rm", "--cached", "--quiet", "--ignore-unmatch", "--"}, topLevelPathspecs(paths)...)
+	}
+
+	result, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "unstaging files failed: %s", strings.TrimSpace(string(result)))
+	}
+	return nil
+}
+
+// Patch returns a staged patch for the given paths (relative to the repository
+// root) that can be re-applied to the index with ApplyCached. Unlike Diff,
+// nothing is excluded and binary changes are included in full.
+func (c *Client) Patch(paths ...string) (string, error) {
+	args := append([]string{
 		"--no-pager",
 		"diff",
 		"--no-ext-diff",
 		"--no-textconv",
+		"--no-color",
 		"--staged",
-		"--diff-filter=ACMRTUXBD",
-		"--",                 // separates options from pathspecs
-		".",                  // include everything under the repo root
-		":(exclude)*-lock.*", // package-lock.json, pnpm-lock.yaml, etc.
-		":(exclude)*.lock",   // yarn.lock, poetry.lock, Cargo.lock, etc.
-		":(exclude)**/build/**",
-		":(exclude)**/dist/**",
-		":(exclude)**/target/**",
-		":(exclude)**/out/**",
-		":(exclude)go.sum",
-	).CombinedOutput()
+		"--binary",
+		"--full-index",
+		"--find-renames",
+		"--src-prefix=a/",
+		"--dst-prefix=b/",
+		"--",
+	}, topLevelPathspecs(paths)...)
 
+	result, err := c.stagedOutput(false, args...)
 	if err != nil {
-		return "", errors.Wrap(err, "git diff failed")
+		return "", errors.Wrap(err, "creating patch failed")
 	}
 	return string(result), nil
 }
 
+// FileAt returns the contents of a file at the given revision, or when rev is
+// blank, as staged.
+func (c *Client) FileAt(rev, path string) (string, error) {
+	result, err := c.output(rev == "", false, "show", rev+":"+path)
+	if err != nil {
+		return "", errors.Wrapf(err, "reading %s:%s failed", rev, path)
+	}
+	return string(result), nil
+}
+
+// ApplyCached applies a patch created by Patch to the index only.
+func (c *Client) ApplyCached(patch string) error {
+	if patch == "" {
+		return nil
+	}
+	defer c.resetPendingIndex()
+
+	topLevel, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return errors.Wrap(err, "locating repository root failed")
+	}
+
+	cmd := exec.Command("git", "apply", "--cached", "--whitespace=nowarn", "-")
+	cmd.Dir = strings.TrimSpace(string(topLevel)) // patch paths are relative to the root
+	cmd.Stdin = strings.NewReader(patch)
+	if result, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "git apply failed: %s", strings.TrimSpace(string(result)))
+	}
+	return nil
+}
+
+// Commit commits the staged changes with the given message. Should git fail,
+// for example because a pre-commit hook rejected the commit, the returned
+// error is an *interfaces.CommitError carrying git's (and the hook's) output.
 func (c *Client) Commit(message string) error {
+	// the hooks may change the staged and pending files, whether or not the
+	// commit goes ahead
+	defer c.resetPendingIndex()
+
 	tmpfile, err := os.CreateTemp("", "gitmsg-*.txt")
 	if err != nil {
 		return err
@@ -99,15 +405,317 @@ func (c *Client) Commit(message string) error {
 	// Set up git commit command
 	cmd := exec.Command("git", "commit", "-F", tmpfile.Name())
 
-	// Connect stdout/stderr of git to our program’s stdout/stderr
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	// Connect stdout/stderr of git to our program’s stdout/stderr, while also
+	// capturing them, so hook output can be shown should the commit fail
+	var output bytes.Buffer
+	cmd.Stdout = io.MultiWriter(os.Stdout, &output)
+	cmd.Stderr = io.MultiWriter(os.Stderr, &output)
 	cmd.Stdin = os.Stdin // allow interactive prompts (e.g., GPG signing, editor, etc.)
 
 	// Run the command
 	if err := cmd.Run(); err != nil {
-		return errors.Wrap(err, "git commit failed")
+		return &interfaces.CommitError{
+			Output: output.String(),
+			Err:    errors.Wrap(err, "git commit failed"),
+		}
 	}
 
 	return nil
 }
+
+// IsPushed reports whether the given commit is reachable from any
+// remote-tracking branch.
+func (c *Client) IsPushed(hash string) (bool, error) {
+	result, err := exec.Command("git", "branch", "--remotes", "--contains", hash).CombinedOutput()
+	if err != nil {
+		return false, errors.Wrapf(err, "git branch failed: %s", strings.TrimSpace(string(result)))
+	}
+	return strings.TrimSpace(string(result)) != "", nil
+}
+
+// Reword rewrites the history of the current branch, replacing the messages
+// of the given commits (keyed by hash) and replaying every commit after them
+// with its original tree, author and message. The working tree and index are
+// left untouched. The previous HEAD is kept in a backup ref, whose name is
+// returned.
+func (c *Client) Reword(messages map[string]string) (string, error) {
+	head, err := revParse("HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	hashes := make([]string, 0, len(messages))
+	for hash := range messages {
+		hashes = append(hashes, hash)
+	}
+	replay, err := replayList(hashes)
+	if err != nil {
+		return "", err
+	}
+
+	backupRef := fmt.Sprintf("refs/git-commit-summary/backup/%d", time.Now().Unix())
+	if err := updateRef("git-commit-summary: backup before reword", backupRef, head, ""); err != nil {
+		return "", err
+	}
+
+	var parent string
+	for i := len(replay) - 1; i >= 0; i-- {
+		hash := replay[i][0]
+		if i == len(replay)-1 && len(replay[i]) > 1 {
+			parent = replay[i][1]
+		}
+
+		message, ok := messages[hash]
+		if !ok {
+			if message, err = commitMessage(hash); err != nil {
+				return "", err
+			}
+		}
+
+		if parent, err = commitTree(hash, parent, message); err != nil {
+			return "", err
+		}
+	}
+
+	if err := updateRef("git-commit-summary: reword", "HEAD", parent, head); err != nil {
+		return "", err
+	}
+	return backupRef, nil
+}
+
+func (c *Client) output(staged bool, combined bool, args ...string) ([]byte, error) {
+	if staged {
+		return c.stagedOutput(combined, args...)
+	}
+
+	cmd := exec.Command("git", args...)
+	if combined {
+		return cmd.CombinedOutput()
+	}
+	return cmd.Output()
+}
+
+// stagedOutput runs a git command that inspects the index. When the client's
+// scope extends beyond the staged changes, the command is run against a
+// temporary copy of the index with the pending changes added to it, so that
+// the real index is left untouched until the commit is confirmed.
+func (c *Client) stagedOutput(combined bool, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+
+	if c.scope != StagedOnly {
+		indexFile, err := c.pendingIndex()
+		if err != nil {
+			return nil, err
+		}
+		cmd.Env = append(os.Environ(), "GIT_INDEX_FILE="+indexFile)
+	}
+
+	if combined {
+		return cmd.CombinedOutput()
+	}
+	return cmd.Output()
+}
+
+// pendingIndex is the temporary index that stagedOutput runs against, built
+// once and then reused by every later query until the index changes.
+func (c *Client) pendingIndex() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.indexFile == "" {
+		indexFile, err := c.temporaryIndex()
+		if err != nil {
+			return "", err
+		}
+		c.indexFile = indexFile
+	}
+	return c.indexFile, nil
+}
+
+// resetPendingIndex discards the temporary index, once the real index, or
+// what is pending, has changed.
+func (c *Client) resetPendingIndex() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.indexFile != "" {
+		_ = os.Remove(c.indexFile) // clean up
+		c.indexFile = ""
+	}
+}
+
+// Close removes the temporary index, if one was built.
+func (c *Client) Close() error {
+	c.resetPendingIndex()
+	return nil
+}
+
+func (c *Client) temporaryIndex() (string, error) {
+	result, err := exec.Command("git", "rev-parse", "--git-path", "index").Output()
+	if err != nil {
+		return "", errors.Wrap(err, "locating git index failed")
+	}
+
+	tmpfile, err := os.CreateTemp("", "git-commit-summary-index-*")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = tmpfile.Close()
+	}()
+
+	index, err := os.Open(strings.TrimSpace(string(result)))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// no commits or staged files yet: let git create a fresh index
+		_ = os.Remove(tmpfile.Name())
+	case err != nil:
+		_ = os.Remove(tmpfile.Name())
+		return "", errors.Wrap(err, "reading git index failed")
+	default:
+		defer func() {
+			_ = index.Close()
+		}()
+		if _, err := io.Copy(tmpfile, index); err != nil {
+			_ = os.Remove(tmpfile.Name())
+			return "", errors.Wrap(err, "copying git index failed")
+		}
+	}
+
+	addFlag := "--update"
+	if c.scope == TrackedAndUntracked {
+		addFlag = "--all"
+	}
+
+	cmd := exec.Command("git", "add", addFlag)
+	cmd.Env = append(os.Environ(), "GIT_INDEX_FILE="+tmpfile.Name())
+	if result, err := cmd.CombinedOutput(); err != nil {
+		_ = os.Remove(tmpfile.Name())
+		return "", errors.Wrapf(err, "git add failed: %s", strings.TrimSpace(string(result)))
+	}
+
+	return tmpfile.Name(), nil
+}
+
+// CheckReword reports whether the given commits can be reworded, before any
+// messages are generated for them: they must be ancestors of HEAD, with no
+// merge commits from the oldest of them onwards.
+func (c *Client) CheckReword(hashes []string) error {
+	_, err := replayList(hashes)
+	return err
+}
+
+// replayList lists the commits, newest first, that need to be replayed to
+// reword the given commits: the oldest of them, and everything after it, each
+// as its hash followed by that of its parent, if any.
+func replayList(hashes []string) ([][]string, error) {
+	result, err := exec.Command("git", "rev-list", "--parents", "HEAD").Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "git rev-list failed")
+	}
+
+	var replay [][]string
+	remaining := len(hashes)
+	for _, line := range strings.Split(strings.TrimSpace(string(result)), "\n") {
+		if remaining == 0 {
+			break
+		}
+		fields := strings.Fields(line)
+		if len(fields) > 2 {
+			return nil, errors.Newf("cannot reword across merge commit %s", fields[0])
+		}
+		if slices.Contains(hashes, fields[0]) {
+			remaining--
+		}
+		replay = append(replay, fields)
+	}
+	if remaining > 0 {
+		return nil, errors.New("commits to reword must be ancestors of HEAD")
+	}
+	return replay, nil
+}
+
+func revParse(rev string) (string, error) {
+	result, err := exec.Command("git", "rev-parse", "--verify", "--quiet", rev).Output()
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to resolve %s", rev)
+	}
+	return strings.TrimSpace(string(result)), nil
+}
+
+func commitMessage(hash string) (string, error) {
+	result, err := exec.Command("git", "log", "-1", "--format=%B", hash).Output()
+	if err != nil {
+		return "", errors.Wrapf(err, "reading message of %s failed", hash)
+	}
+	return string(result), nil
+}
+
+// commitTree creates a copy of the given commit, with a new parent and message
+// but the same tree and authorship, returning the new commit's hash.
+func commitTree(hash, parent, message string) (string, error) {
+	result, err := exec.Command("git", "log", "-1", "--format=%an%x00%ae%x00%ad", "--date=raw", hash).Output()
+	if err != nil {
+		return "", errors.Wrapf(err, "reading author of %s failed", hash)
+	}
+	author := strings.SplitN(strings.TrimSpace(string(result)), "\x00", 3)
+	if len(author) != 3 {
+		return "", errors.Newf("unexpected author of %s: %q", hash, result)
+	}
+
+	args := []string{"commit-tree", hash + "^{tree}"}
+	if parent != "" {
+		args = append(args, "-p", parent)
+	}
+	args = append(args, "-F", "-")
+
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME="+author[0],
+		"GIT_AUTHOR_EMAIL="+author[1],
+		"GIT_AUTHOR_DATE="+author[2],
+	)
+	cmd.Stdin = strings.NewReader(message)
+	result, err = cmd.Output()
+	if err != nil {
+		return "", errors.Wrapf(err, "rewriting %s failed", hash)
+	}
+	return strings.TrimSpace(string(result)), nil
+}
+
+func updateRef(reason, ref, newValue, oldValue string) error {
+	args := []string{"update-ref", "-m", reason, ref, newValue}
+	if oldValue != "" {
+		args = append(args, oldValue)
+	}
+	if result, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "git update-ref failed: %s", strings.TrimSpace(string(result)))
+	}
+	return nil
+}
+
+// revOrEmptyTree returns the given revision, or when blank, the hash of the
+// empty tree, so that the changes introduced by a root commit can be shown.
+func revOrEmptyTree(rev string) (string, error) {
+	if rev != "" {
+		return rev, nil
+	}
+
+	cmd := exec.Command("git", "hash-object", "-t", "tree", "--stdin")
+	cmd.Stdin = strings.NewReader("")
+	result, err := cmd.Output()
+	if err != nil {
+		return "", errors.Wrap(err, "hashing empty tree failed")
+	}
+	return strings.TrimSpace(string(result)), nil
+}
+
+// topLevelPathspecs turns repository-relative paths into pathspecs that are
+// interpreted literally, regardless of the current working directory.
+func topLevelPathspecs(paths []string) []string {
+	pathspecs := make([]string, len(paths))
+	for i, path := range paths {
+		pathspecs[i] = ":(top,literal)" + path
+	}
+	return pathspecs
+}
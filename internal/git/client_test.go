@@ -0,0 +1,172 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rm-hull/git-commit-summary/internal/analysis"
+	"github.com/rm-hull/git-commit-summary/internal/interfaces"
+)
+
+// testRepo creates a repository with a single commit of a.txt and b.txt, and
+// makes it the working directory for the rest of the test.
+func testRepo(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	run(t, "init", "--quiet")
+	run(t, "config", "user.name", "Test")
+	run(t, "config", "user.email", "test@example.com")
+	run(t, "config", "commit.gpgsign", "false")
+	write(t, "a.txt", "a\n")
+	write(t, "b.txt", "b\n")
+	run(t, "add", ".")
+	run(t, "commit", "--quiet", "--no-verify", "-m", "initial")
+}
+
+func run(t *testing.T, args ...string) {
+	t.Helper()
+	output, err := exec.Command("git", args...).CombinedOutput()
+	require.NoError(t, err, string(output))
+}
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestPendingFiles(t *testing.T) {
+	setup := func(t *testing.T) {
+		testRepo(t)
+		write(t, "a.txt", "changed\n")
+		require.NoError(t, os.Remove("b.txt"))
+		write(t, "sub/new.txt", "new\n")
+		t.Chdir("sub")
+	}
+
+	t.Run("Staged only", func(t *testing.T) {
+		setup(t)
+		files, err := NewClient(StagedOnly).PendingFiles()
+		assert.NoError(t, err)
+		assert.Empty(t, files)
+	})
+
+	t.Run("Tracked", func(t *testing.T) {
+		setup(t)
+		files, err := NewClient(Tracked).PendingFiles()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a.txt", "b.txt"}, files)
+	})
+
+	t.Run("Tracked and untracked", func(t *testing.T) {
+		setup(t)
+		files, err := NewClient(TrackedAndUntracked).PendingFiles()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a.txt", "b.txt", "sub/new.txt"}, files)
+	})
+}
+
+func TestStage(t *testing.T) {
+	testRepo(t)
+	write(t, "a.txt", "changed\n")
+	require.NoError(t, os.Remove("b.txt"))
+	write(t, "c.txt", "new\n")
+
+	client := NewClient(StagedOnly)
+	assert.NoError(t, client.Stage(nil))
+	assert.NoError(t, client.Stage([]string{"a.txt", "b.txt"}))
+
+	changes, err := client.StagedChanges()
+	assert.NoError(t, err)
+	assert.Equal(t, []interfaces.FileChange{
+		{Status: "M", Path: "a.txt", Added: 1, Removed: 1},
+		{Status: "D", Path: "b.txt", Removed: 1},
+	}, changes)
+}
+
+func TestPendingIndex(t *testing.T) {
+	testRepo(t)
+	write(t, "a.txt", "changed\n")
+
+	client := NewClient(Tracked)
+	defer func() {
+		_ = client.Close()
+	}()
+
+	changes, err := client.StagedChanges()
+	assert.NoError(t, err)
+	assert.Equal(t, []interfaces.FileChange{{Status: "M", Path: "a.txt", Added: 1, Removed: 1}}, changes)
+
+	indexFile := client.indexFile
+	assert.FileExists(t, indexFile)
+
+	_, err = client.Diff()
+	assert.NoError(t, err)
+	assert.Equal(t, indexFile, client.indexFile, "the index is built once and reused")
+
+	write(t, "b.txt", "changed\n")
+	assert.NoError(t, client.Stage([]string{"b.txt"}))
+	assert.NoFileExists(t, indexFile, "staging discards the index")
+
+	changes, err = client.StagedChanges()
+	assert.NoError(t, err)
+	assert.Len(t, changes, 2)
+
+	indexFile = client.indexFile
+	assert.NoError(t, client.Close())
+	assert.NoFileExists(t, indexFile)
+}
+
+func TestCheckReword(t *testing.T) {
+	testRepo(t)
+	write(t, "a.txt", "changed\n")
+	run(t, "commit", "--quiet", "--no-verify", "-am", "second")
+
+	commits, err := NewClient(StagedOnly).Commits("HEAD~1")
+	require.NoError(t, err)
+	require.Len(t, commits, 1)
+	assert.NoError(t, NewClient(StagedOnly).CheckReword([]string{commits[0].Hash}))
+
+	run(t, "checkout", "--quiet", "-b", "side", "HEAD~1")
+	err = NewClient(StagedOnly).CheckReword([]string{commits[0].Hash})
+	assert.EqualError(t, err, "commits to reword must be ancestors of HEAD")
+}
+
+func TestDiffPrefixes(t *testing.T) {
+	testRepo(t)
+	run(t, "config", "diff.mnemonicPrefix", "true")
+	write(t, "a.txt", "changed\n")
+	run(t, "add", "a.txt")
+
+	diff, err := NewClient(StagedOnly).Diff()
+	assert.NoError(t, err)
+	assert.Contains(t, diff, "diff --git a/a.txt b/a.txt\n")
+	assert.Contains(t, diff, "+++ b/a.txt\n")
+
+	run(t, "config", "diff.noprefix", "true")
+	diff, err = NewClient(StagedOnly).Diff()
+	assert.NoError(t, err)
+	assert.Contains(t, diff, "+++ b/a.txt\n")
+}
+
+func TestAnnotateStagedDiff(t *testing.T) {
+	testRepo(t)
+	run(t, "config", "diff.mnemonicPrefix", "true")
+	write(t, "main.go", "package main\n\nfunc main() {\n\tprintln(\"a\")\n}\n")
+	run(t, "add", "main.go")
+	run(t, "commit", "--quiet", "--no-verify", "-m", "add main")
+	write(t, "main.go", "package main\n\nfunc main() {\n\tprintln(\"b\")\n}\n")
+	run(t, "add", "main.go")
+
+	client := NewClient(StagedOnly)
+	diff, err := client.Diff()
+	require.NoError(t, err)
+	assert.Contains(t, analysis.Annotate(client, "", diff, analysis.ContextNames), "@@ main\n")
+}
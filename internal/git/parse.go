@@ -0,0 +1,123 @@
+package git
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/rm-hull/git-commit-summary/internal/interfaces"
+)
+
+// parseNameStatus parses the NUL-separated output of `git diff --name-status -z`.
+// Renames and copies carry a similarity score (e.g. R087) and are followed by
+// both the old and the new path.
+func parseNameStatus(output string) ([]interfaces.FileChange, error) {
+	fields := splitNul(output)
+	changes := make([]interfaces.FileChange, 0, len(fields)/2)
+
+	for i := 0; i < len(fields); i++ {
+		status := fields[i]
+		if status == "" {
+			continue
+		}
+
+		change := interfaces.FileChange{Status: status[:1]}
+		switch change.Status {
+		case "R", "C":
+			if i+2 >= len(fields) {
+				return nil, errors.Newf("malformed name-status output for %q", status)
+			}
+			change.OldPath = fields[i+1]
+			change.Path = fields[i+2]
+			i += 2
+		default:
+			if i+1 >= len(fields) {
+				return nil, errors.Newf("malformed name-status output for %q", status)
+			}
+			change.Path = fields[i+1]
+			i++
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// mergeNumstat parses the NUL-separated output of `git diff --numstat -z` and
+// copies the line counts onto the matching changes. Binary files are reported
+// by git with "-" in place of the counts.
+func mergeNumstat(changes []interfaces.FileChange, output string) error {
+	byPath := make(map[string]*interfaces.FileChange, len(changes))
+	for i := range changes {
+		byPath[changes[i].Path] = &changes[i]
+	}
+
+	fields := splitNul(output)
+	for i := 0; i < len(fields); i++ {
+		if fields[i] == "" {
+			continue
+		}
+
+		line := fields[i]
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) != 3 {
+			return errors.Newf("malformed numstat output: %q", line)
+		}
+
+		path := parts[2]
+		if path == "" {
+			// renames and copies: the old and new paths follow as separate fields
+			if i+2 >= len(fields) {
+				return errors.Newf("malformed numstat output: %q", line)
+			}
+			path = fields[i+2]
+			i += 2
+		}
+
+		change, ok := byPath[path]
+		if !ok {
+			continue
+		}
+
+		if parts[0] == "-" && parts[1] == "-" {
+			change.Binary = true
+			continue
+		}
+
+		var err error
+		if change.Added, err = strconv.Atoi(parts[0]); err != nil {
+			return errors.Wrapf(err, "malformed numstat output: %q", line)
+		}
+		if change.Removed, err = strconv.Atoi(parts[1]); err != nil {
+			return errors.Wrapf(err, "malformed numstat output: %q", line)
+		}
+	}
+
+	return nil
+}
+
+// parseLog parses `git log --format=%H%x1f%P%x1f%B%x00` output, where each
+// commit is NUL-terminated and its fields are separated by the unit separator.
+func parseLog(output string) []interfaces.Commit {
+	var commits []interfaces.Commit
+	for _, record := range splitNul(output) {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x1f", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		commits = append(commits, interfaces.Commit{
+			Hash:    fields[0],
+			Parents: strings.Fields(fields[1]),
+			Message: strings.TrimSpace(fields[2]),
+		})
+	}
+	return commits
+}
+
+func splitNul(output string) []string {
+	trimmed := strings.TrimRight(output, "\x00")
+	if trimmed == "" {
+		return nil
+	}
+	return strings.Split(trimmed, "\x00")
+}
@@ -0,0 +1,71 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rm-hull/git-commit-summary/internal/interfaces"
+)
+
+func TestParseNameStatus(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		changes, err := parseNameStatus("")
+		assert.NoError(t, err)
+		assert.Empty(t, changes)
+	})
+
+	t.Run("Mixed statuses", func(t *testing.T) {
+		output := "M\x00main.go\x00A\x00new.go\x00D\x00logo.png\x00R087\x00old/name.go\x00new/name.go\x00"
+		changes, err := parseNameStatus(output)
+		assert.NoError(t, err)
+		assert.Equal(t, []interfaces.FileChange{
+			{Status: "M", Path: "main.go"},
+			{Status: "A", Path: "new.go"},
+			{Status: "D", Path: "logo.png"},
+			{Status: "R", OldPath: "old/name.go", Path: "new/name.go"},
+		}, changes)
+	})
+
+	t.Run("Truncated rename", func(t *testing.T) {
+		_, err := parseNameStatus("R100\x00old.go\x00")
+		assert.Error(t, err)
+	})
+}
+
+func TestMergeNumstat(t *testing.T) {
+	t.Run("Counts, binaries and renames", func(t *testing.T) {
+		changes := []interfaces.FileChange{
+			{Status: "M", Path: "main.go"},
+			{Status: "D", Path: "logo.png"},
+			{Status: "R", OldPath: "old/name.go", Path: "new/name.go"},
+		}
+		output := "10\t2\tmain.go\x00-\t-\tlogo.png\x003\t1\t\x00old/name.go\x00new/name.go\x00"
+
+		err := mergeNumstat(changes, output)
+		assert.NoError(t, err)
+		assert.Equal(t, []interfaces.FileChange{
+			{Status: "M", Path: "main.go", Added: 10, Removed: 2},
+			{Status: "D", Path: "logo.png", Binary: true},
+			{Status: "R", OldPath: "old/name.go", Path: "new/name.go", Added: 3, Removed: 1},
+		}, changes)
+	})
+
+	t.Run("Malformed line", func(t *testing.T) {
+		err := mergeNumstat(nil, "garbage\x00")
+		assert.Error(t, err)
+	})
+}
+
+func TestParseLog(t *testing.T) {
+	output := "" +
+		"aaa\x1f\x1ffeat: initial commit\n\x00" +
+		"\nbbb\x1faaa\x1fwip\n\nmore details\n\x00" +
+		"\nccc\x1fbbb ddd\x1fMerge branch 'x'\n\x00\n"
+
+	assert.Equal(t, []interfaces.Commit{
+		{Hash: "aaa", Parents: []string{}, Message: "feat: initial commit"},
+		{Hash: "bbb", Parents: []string{"aaa"}, Message: "wip\n\nmore details"},
+		{Hash: "ccc", Parents: []string{"bbb", "ddd"}, Message: "Merge branch 'x'"},
+	}, parseLog(output))
+}
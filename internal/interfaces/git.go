@@ -4,9 +4,58 @@ import "github.com/cockroachdb/errors"
 
 var ErrAborted = errors.New("aborted")
 
+// CommitError is returned when git refuses to commit, for example because a
+// pre-commit hook failed, and carries the output of git and its hooks.
+type CommitError struct {
+	Output string
+	Err    error
+}
+
+func (e *CommitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CommitError) Unwrap() error {
+	return e.Err
+}
+
+// FileChange describes a single staged file, as reported by
+// `git diff --name-status` and `git diff --numstat`.
+type FileChange struct {
+	Status  string // A, M, D, R, C, T, U or X
+	OldPath string // only set for renames and copies
+	Path    string
+	Binary  bool
+	Added   int
+	Removed int
+}
+
+// Commit is an existing commit, as reported by `git log`.
+type Commit struct {
+	Hash    string
+	Parents []string
+	Message string
+}
+
 type GitClient interface {
 	IsInWorkTree() error
-	StagedFiles() ([]string, error)
-	Diff() (string, error)
+	GitDir() (string, error)
+	StagedChanges(paths ...string) ([]FileChange, error)
+	Diff(paths ...string) (string, error)
+	Commits(revRange string) ([]Commit, error)
+	ChangesBetween(from, to string) ([]FileChange, error)
+	DiffBetween(from, to string) (string, error)
+	PendingFiles() ([]string, error)
+	UnstagedFiles(paths []string) ([]string, error)
+	Stage(paths []string) error
+	Unstage(paths []string) error
+	Patch(paths ...string) (string, error)
+	FileAt(rev, path string) (string, error)
+	ApplyCached(patch string) error
 	Commit(message string) error
+	IsPushed(hash string) (bool, error)
+	Identity() (string, error)
+	RecentAuthors(limit int) ([]string, error)
+	CheckReword(hashes []string) error
+	Reword(messages map[string]string) (string, error)
 }
@@ -0,0 +1,273 @@
+package keys
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cockroachdb/errors"
+)
+
+// Map holds the key bindings of the commit message editor, and of the panels
+// opened from it.
+type Map struct {
+	Commit          key.Binding
+	Abort           key.Binding
+	Clear           key.Binding
+	Undo            key.Binding
+	Redo            key.Binding
+	Regenerate      key.Binding
+	PreviousVersion key.Binding
+	NextVersion     key.Binding
+	Compare         key.Binding
+	Preview         key.Binding
+	Changes         key.Binding
+	Trailers        key.Binding
+	Help            key.Binding
+
+	TrailerPanel TrailerMap
+	ChangesPanel ChangesMap
+}
+
+// TrailerMap holds the key bindings of the panel for choosing trailers.
+type TrailerMap struct {
+	Up     key.Binding
+	Down   key.Binding
+	Toggle key.Binding
+	Add    key.Binding
+	Done   key.Binding
+}
+
+// ChangesMap holds the key bindings of the pane showing the staged changes,
+// besides those scrolling it.
+type ChangesMap struct {
+	NextFile     key.Binding
+	PreviousFile key.Binding
+	Back         key.Binding
+}
+
+// The views that the bindings are used in. A key may be bound to actions in
+// different views, but only once in each.
+const (
+	editorView   = "editor"
+	trailersView = "trailers"
+	changesView  = "changes"
+)
+
+// action is a binding that can be configured, by its name.
+type action struct {
+	name    string
+	view    string
+	binding func(*Map) *key.Binding
+	closes  string // the panel the binding also closes, if any
+}
+
+var actions = []action{
+	{"commit", editorView, func(m *Map) *key.Binding { return &m.Commit }, ""},
+	{"abort", editorView, func(m *Map) *key.Binding { return &m.Abort }, ""},
+	{"clear", editorView, func(m *Map) *key.Binding { return &m.Clear }, ""},
+	{"undo", editorView, func(m *Map) *key.Binding { return &m.Undo }, ""},
+	{"redo", editorView, func(m *Map) *key.Binding { return &m.Redo }, ""},
+	{"regenerate", editorView, func(m *Map) *key.Binding { return &m.Regenerate }, ""},
+	{"previous-version", editorView, func(m *Map) *key.Binding { return &m.PreviousVersion }, ""},
+	{"next-version", editorView, func(m *Map) *key.Binding { return &m.NextVersion }, ""},
+	{"compare", editorView, func(m *Map) *key.Binding { return &m.Compare }, ""},
+	{"preview", editorView, func(m *Map) *key.Binding { return &m.Preview }, ""},
+	{"changes", editorView, func(m *Map) *key.Binding { return &m.Changes }, changesView},
+	{"trailers", editorView, func(m *Map) *key.Binding { return &m.Trailers }, trailersView},
+	{"help", editorView, func(m *Map) *key.Binding { return &m.Help }, ""},
+	{"trailer-up", trailersView, func(m *Map) *key.Binding { return &m.TrailerPanel.Up }, ""},
+	{"trailer-down", trailersView, func(m *Map) *key.Binding { return &m.TrailerPanel.Down }, ""},
+	{"trailer-toggle", trailersView, func(m *Map) *key.Binding { return &m.TrailerPanel.Toggle }, ""},
+	{"trailer-add", trailersView, func(m *Map) *key.Binding { return &m.TrailerPanel.Add }, ""},
+	{"trailer-done", trailersView, func(m *Map) *key.Binding { return &m.TrailerPanel.Done }, ""},
+	{"changes-next-file", changesView, func(m *Map) *key.Binding { return &m.ChangesPanel.NextFile }, ""},
+	{"changes-previous-file", changesView, func(m *Map) *key.Binding { return &m.ChangesPanel.PreviousFile }, ""},
+	{"changes-back", changesView, func(m *Map) *key.Binding { return &m.ChangesPanel.Back }, ""},
+}
+
+// editingKeys are needed to type the message, and so cannot be bound to any
+// action of the editor.
+var editingKeys = []string{"enter", "tab", "backspace", "delete", "up", "down", "left", "right", "home", "end"}
+
+// keyNames are the names bubbletea gives the keys that are not characters,
+// such as ctrl+s or pgup.
+var keyNames = func() map[string]bool {
+	names := map[string]bool{}
+	for k := tea.KeyType(-1000); k < 1000; k++ {
+		if name := k.String(); name != "" && k != tea.KeyRunes {
+			names[name] = true
+		}
+	}
+	return names
+}()
+
+// Actions are the names of the bindings that can be configured.
+func Actions() []string {
+	names := make([]string, len(actions))
+	for i, a := range actions {
+		names[i] = a.name
+	}
+	return names
+}
+
+// Default is the bindings used unless configured otherwise.
+func Default() Map {
+	return Map{
+		Commit:          binding("commit", "ctrl+x"),
+		Abort:           binding("abort", "esc"),
+		Clear:           binding("clear", "ctrl+k"),
+		Undo:            binding("undo", "ctrl+z"),
+		Redo:            binding("redo", "ctrl+y"),
+		Regenerate:      binding("regen", "ctrl+r"),
+		PreviousVersion: binding("previous version", "pgup"),
+		NextVersion:     binding("next version", "pgdown"),
+		Compare:         binding("diff", "ctrl+g"),
+		Preview:         binding("preview", "ctrl+p"),
+		Changes:         binding("changes", "ctrl+o"),
+		Trailers:        binding("trailers", "ctrl+t"),
+		Help:            binding("help", "f1", "?"),
+		TrailerPanel: TrailerMap{
+			Up:     binding("up", "up", "k"),
+			Down:   binding("down", "down", "j"),
+			Toggle: binding("toggle", " ", "x"),
+			Add:    binding("add", "a"),
+			Done:   binding("done", "enter", "esc"),
+		},
+		ChangesPanel: ChangesMap{
+			NextFile:     binding("next file", "n", "tab"),
+			PreviousFile: binding("previous file", "p", "shift+tab"),
+			Back:         binding("back", "esc", "q"),
+		},
+	}
+}
+
+func binding(description string, keys ...string) key.Binding {
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = k
+		if k == " " {
+			names[i] = "space"
+		}
+	}
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp(strings.Join(names, "/"), description))
+}
+
+// Parse overrides the default bindings with those written as `action=keys`,
+// where the keys are separated by `|`, e.g. `regenerate=f5|alt+r`. Each key
+// may only be bound to one action of a view. Only the help may be bound to a
+// printable character, as any other would be typed into the message, and the
+// keys needed to edit the message cannot be bound at all.
+func Parse(entries ...string) (Map, error) {
+	m := Default()
+	for _, entry := range entries {
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || name == "" || value == "" {
+			return Map{}, errors.Newf("expected action=keys, got %q", entry)
+		}
+
+		idx := slices.IndexFunc(actions, func(a action) bool { return a.name == name })
+		if idx < 0 {
+			return Map{}, errors.Newf("unknown action %q, expected one of: %s", name, strings.Join(Actions(), ", "))
+		}
+
+		b := actions[idx].binding(&m)
+		keys := strings.Split(value, "|")
+		for i, k := range keys {
+			if k == "space" {
+				keys[i] = " " // as bubbletea names it
+			}
+		}
+		*b = binding(b.Help().Desc, keys...)
+	}
+
+	if err := m.Validate(); err != nil {
+		return Map{}, err
+	}
+	return m, nil
+}
+
+// Validate reports a key that does not exist, a key bound to more than one
+// action of a view, or a key that the editor needs for itself: a printable
+// character bound to any action other than the help, or a key needed to edit
+// the message. CTRL+C always quits, and so cannot be bound.
+func (m Map) Validate() error {
+	bound := map[string]map[string]string{
+		editorView:   {},
+		trailersView: {},
+		changesView:  {},
+	}
+	for _, b := range scrollBindings() {
+		for _, k := range b.Keys() {
+			bound[changesView][k] = "scrolling"
+		}
+	}
+
+	for _, a := range actions {
+		for _, k := range a.binding(&m).Keys() {
+			if k == "" {
+				return errors.Newf("empty key bound to %s", a.name)
+			}
+			if !validKey(k) {
+				return errors.Newf("unknown key %q bound to %s", k, a.name)
+			}
+			if k == "ctrl+c" {
+				return errors.Newf("ctrl+c always quits, so cannot be bound to %s", a.name)
+			}
+			if a.view == editorView {
+				if utf8.RuneCountInString(k) == 1 && a.name != "help" {
+					return errors.Newf("%q cannot be bound to %s, as it would be typed into the message", k, a.name)
+				}
+				if slices.Contains(editingKeys, k) {
+					return errors.Newf("%s is needed to edit the message, so cannot be bound to %s", k, a.name)
+				}
+			}
+			for _, view := range []string{a.view, a.closes} {
+				if view == "" {
+					continue
+				}
+				if other, ok := bound[view][k]; ok {
+					return errors.Newf("%s is bound to both %s and %s", k, other, a.name)
+				}
+				bound[view][k] = a.name
+			}
+		}
+	}
+	return nil
+}
+
+// validKey reports whether bubbletea names a key press so: a character, or the
+// name of another key, either optionally with alt held.
+func validKey(k string) bool {
+	if utf8.RuneCountInString(k) == 1 {
+		return true
+	}
+	k = strings.TrimPrefix(k, "alt+")
+	return utf8.RuneCountInString(k) == 1 || keyNames[k]
+}
+
+// scrollBindings are the keys the changes pane scrolls with.
+func scrollBindings() []key.Binding {
+	km := viewport.DefaultKeyMap()
+	return []key.Binding{km.PageDown, km.PageUp, km.HalfPageUp, km.HalfPageDown, km.Up, km.Down, km.Left, km.Right}
+}
+
+// Group is a titled column of the full help.
+type Group struct {
+	Title    string
+	Bindings []key.Binding
+}
+
+// FullHelp lists every binding, in groups.
+func (m Map) FullHelp() []Group {
+	return []Group{
+		{Title: "Message", Bindings: []key.Binding{m.Commit, m.Abort, m.Clear, m.Undo, m.Redo}},
+		{Title: "Generating", Bindings: []key.Binding{m.Regenerate, m.PreviousVersion, m.NextVersion, m.Compare}},
+		{Title: "Views", Bindings: []key.Binding{m.Preview, m.Changes, m.Trailers, m.Help}},
+		{Title: "Trailers", Bindings: []key.Binding{m.TrailerPanel.Up, m.TrailerPanel.Down, m.TrailerPanel.Toggle, m.TrailerPanel.Add, m.TrailerPanel.Done}},
+		{Title: "Changes", Bindings: []key.Binding{m.ChangesPanel.NextFile, m.ChangesPanel.PreviousFile, m.ChangesPanel.Back}},
+	}
+}
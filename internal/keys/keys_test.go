@@ -0,0 +1,98 @@
+package keys
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefault(t *testing.T) {
+	m := Default()
+	assert.NoError(t, m.Validate())
+	assert.True(t, key.Matches(tea.KeyMsg{Type: tea.KeyCtrlX}, m.Commit))
+	assert.True(t, key.Matches(tea.KeyMsg{Type: tea.KeyPgUp}, m.PreviousVersion))
+	assert.True(t, key.Matches(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'?'}}, m.Help))
+	assert.Equal(t, "ctrl+y", m.Redo.Help().Key)
+}
+
+func TestParse(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		m, err := Parse()
+		assert.NoError(t, err)
+		assert.Equal(t, Default().Commit.Keys(), m.Commit.Keys())
+	})
+
+	t.Run("Overrides", func(t *testing.T) {
+		m, err := Parse("commit=ctrl+s", "regenerate=ctrl+e|f5")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"ctrl+s"}, m.Commit.Keys())
+		assert.Equal(t, []string{"ctrl+e", "f5"}, m.Regenerate.Keys())
+		assert.Equal(t, "ctrl+e/f5", m.Regenerate.Help().Key)
+		assert.Equal(t, "regen", m.Regenerate.Help().Desc)
+		assert.True(t, key.Matches(tea.KeyMsg{Type: tea.KeyCtrlS}, m.Commit))
+		assert.False(t, key.Matches(tea.KeyMsg{Type: tea.KeyCtrlX}, m.Commit))
+	})
+
+	t.Run("Swapped", func(t *testing.T) {
+		m, err := Parse("commit=ctrl+r", "regenerate=ctrl+x")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"ctrl+r"}, m.Commit.Keys())
+	})
+
+	t.Run("Documented example", func(t *testing.T) {
+		m, err := Parse("regenerate=f5|alt+r")
+		assert.NoError(t, err)
+		assert.True(t, key.Matches(tea.KeyMsg{Type: tea.KeyF5}, m.Regenerate))
+		assert.True(t, key.Matches(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}, Alt: true}, m.Regenerate))
+	})
+
+	t.Run("Panels", func(t *testing.T) {
+		m, err := Parse("trailer-toggle=space|enter", "trailer-done=esc", "changes-back=esc|ctrl+t")
+		assert.NoError(t, err)
+		assert.True(t, key.Matches(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}, m.TrailerPanel.Toggle))
+		assert.Equal(t, "space/enter", m.TrailerPanel.Toggle.Help().Key)
+		assert.True(t, key.Matches(tea.KeyMsg{Type: tea.KeyCtrlT}, m.ChangesPanel.Back))
+	})
+
+	for name, text := range map[string]string{
+		"Malformed":         "commit",
+		"Unknown action":    "save=ctrl+s",
+		"Conflict":          "commit=ctrl+r",
+		"Printable":         "commit=x",
+		"Quit":              "abort=ctrl+c",
+		"Empty key":         "undo=ctrl+z|",
+		"Typo":              "commit=ctl+s",
+		"Unknown key":       "commit=ctrl+shift+s",
+		"Editing key":       "commit=enter",
+		"Arrow":             "next-version=down",
+		"Panel conflict":    "trailer-add=k",
+		"Closing the panel": "trailer-add=ctrl+t",
+		"Scrolling":         "changes-next-file=j",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(text)
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("Conflict message", func(t *testing.T) {
+		_, err := Parse("preview=ctrl+x")
+		assert.EqualError(t, err, "ctrl+x is bound to both commit and preview")
+
+		_, err = Parse("commit=ctl+s")
+		assert.EqualError(t, err, `unknown key "ctl+s" bound to commit`)
+
+		_, err = Parse("commit=tab")
+		assert.EqualError(t, err, "tab is needed to edit the message, so cannot be bound to commit")
+	})
+}
+
+func TestFullHelp(t *testing.T) {
+	var count int
+	for _, group := range Default().FullHelp() {
+		count += len(group.Bindings)
+	}
+	assert.Equal(t, len(Actions()), count)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/cockroachdb/errors"
 	"github.com/rm-hull/git-commit-summary/internal/config"
+	"github.com/rm-hull/git-commit-summary/internal/message"
 	"google.golang.org/genai"
 )
 
@@ -28,10 +29,14 @@ func NewGoogleProvider(ctx context.Context, cfg *config.Config) (Provider, error
 }
 
 func (provider *GoogleProvider) Call(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	return provider.Chat(ctx, systemPrompt, []Message{{Role: RoleUser, Content: userPrompt}})
+}
+
+func (provider *GoogleProvider) Chat(ctx context.Context, systemPrompt string, messages []Message) (string, error) {
 	result, err := provider.client.Models.GenerateContent(
 		ctx,
 		provider.model,
-		genai.Text(userPrompt),
+		geminiContents(messages),
 		nil,
 	)
 	if err != nil {
@@ -41,6 +46,57 @@ func (provider *GoogleProvider) Call(ctx context.Context, systemPrompt, userProm
 	return result.Text(), nil
 }
 
+func (provider *GoogleProvider) CallStructured(ctx context.Context, systemPrompt string, messages []Message) (message.Structured, error) {
+	result, err := provider.client.Models.GenerateContent(
+		ctx,
+		provider.model,
+		geminiContents(messages),
+		&genai.GenerateContentConfig{
+			ResponseMIMEType: "application/json",
+			ResponseSchema:   geminiSchema(),
+		},
+	)
+	if err != nil {
+		return message.Structured{}, markUnsupported(errors.Wrap(err, "failed to generate content:"))
+	}
+
+	return parseStructured(result.Text())
+}
+
+func geminiContents(messages []Message) []*genai.Content {
+	contents := make([]*genai.Content, len(messages))
+	for i, msg := range messages {
+		role := genai.RoleUser
+		if msg.Role == RoleAssistant {
+			role = genai.RoleModel
+		}
+		contents[i] = genai.NewContentFromText(msg.Content, genai.Role(role))
+	}
+	return contents
+}
+
+// geminiSchema is the commit schema in Gemini's subset of OpenAPI.
+func geminiSchema() *genai.Schema {
+	schema := &genai.Schema{
+		Type:       genai.TypeObject,
+		Properties: map[string]*genai.Schema{},
+	}
+	for _, field := range commitSchema {
+		property := &genai.Schema{Type: genai.TypeString, Description: field.description, Enum: field.enum}
+		if field.kind == "array" {
+			property.Type = genai.TypeArray
+			property.Items = &genai.Schema{Type: genai.TypeString}
+		}
+		if field.enum != nil {
+			property.Format = "enum"
+		}
+		schema.Properties[field.name] = property
+		schema.PropertyOrdering = append(schema.PropertyOrdering, field.name)
+	}
+	schema.Required = []string{"type", "subject"}
+	return schema
+}
+
 func (provider *GoogleProvider) Model() string {
 	return provider.model
 }
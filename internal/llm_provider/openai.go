@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/rm-hull/git-commit-summary/internal/config"
+	"github.com/rm-hull/git-commit-summary/internal/message"
 
 	"github.com/cockroachdb/errors"
 	openai "github.com/openai/openai-go/v3"
@@ -27,13 +28,14 @@ func NewOpenAiProvider(ctx context.Context, cfg *config.Config) (Provider, error
 }
 
 func (provider *OpenAiProvider) Call(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	return provider.Chat(ctx, systemPrompt, []Message{{Role: RoleUser, Content: userPrompt}})
+}
+
+func (provider *OpenAiProvider) Chat(ctx context.Context, systemPrompt string, messages []Message) (string, error) {
 	result, err := provider.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
 		Temperature: openai.Float(0.1),
 		Model:       provider.model,
-		Messages: []openai.ChatCompletionMessageParamUnion{
-			openai.SystemMessage(systemPrompt),
-			openai.UserMessage(userPrompt),
-		},
+		Messages:    openAIMessages(systemPrompt, messages),
 	})
 	if err != nil {
 		return "", errors.Wrap(err, "failed to generate content")
@@ -42,6 +44,65 @@ func (provider *OpenAiProvider) Call(ctx context.Context, systemPrompt, userProm
 	return result.Choices[0].Message.Content, nil
 }
 
+func (provider *OpenAiProvider) CallStructured(ctx context.Context, systemPrompt string, messages []Message) (message.Structured, error) {
+	result, err := provider.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+		Temperature: openai.Float(0.1),
+		Model:       provider.model,
+		Messages:    openAIMessages(systemPrompt, messages),
+		ResponseFormat: openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONSchema: &openai.ResponseFormatJSONSchemaParam{
+				JSONSchema: openai.ResponseFormatJSONSchemaJSONSchemaParam{
+					Name:   "commit_message",
+					Strict: openai.Bool(true),
+					Schema: openAISchema(),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return message.Structured{}, markUnsupported(errors.Wrap(err, "failed to generate content"))
+	}
+
+	return parseStructured(result.Choices[0].Message.Content)
+}
+
+func openAIMessages(systemPrompt string, messages []Message) []openai.ChatCompletionMessageParamUnion {
+	params := []openai.ChatCompletionMessageParamUnion{openai.SystemMessage(systemPrompt)}
+	for _, msg := range messages {
+		if msg.Role == RoleAssistant {
+			params = append(params, openai.AssistantMessage(msg.Content))
+		} else {
+			params = append(params, openai.UserMessage(msg.Content))
+		}
+	}
+	return params
+}
+
+// openAISchema is the commit schema in JSON Schema, where strict mode needs
+// every property to be required.
+func openAISchema() map[string]any {
+	properties := map[string]any{}
+	var required []string
+	for _, field := range commitSchema {
+		property := map[string]any{"type": field.kind, "description": field.description}
+		if field.kind == "array" {
+			property["items"] = map[string]any{"type": "string"}
+		}
+		if field.enum != nil {
+			property["enum"] = field.enum
+		}
+		properties[field.name] = property
+		required = append(required, field.name)
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
 func (provider *OpenAiProvider) Model() string {
 	return provider.model
 }
@@ -7,8 +7,24 @@ import (
 	"github.com/rm-hull/git-commit-summary/internal/config"
 )
 
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+// Message is a turn in a conversation with the model.
+type Message struct {
+	Role    Role
+	Content string
+}
+
 type Provider interface {
 	Call(ctx context.Context, systemPrompt, userPrompt string) (string, error)
+	// Chat continues a conversation, in which the model's previous answers
+	// and the user's refinements of them follow the first prompt.
+	Chat(ctx context.Context, systemPrompt string, messages []Message) (string, error)
 	Model() string
 }
 
@@ -0,0 +1,175 @@
+package llmprovider
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	thinking     = regexp.MustCompile(`(?is)<(think|thinking|thought|reasoning)>.*?</(think|thinking|thought|reasoning)>`)
+	thinkingEnd  = regexp.MustCompile(`(?i)^\s*</(think|thinking|thought|reasoning)>\s*$`)
+	subjectLike  = regexp.MustCompile(`^[a-zA-Z]+(\([^)]*\))?!?:\s`)
+	finalChannel = regexp.MustCompile(`(?s)^.*<\|channel\|>final<\|message\|>`)
+	specialToken = regexp.MustCompile(`<\|[a-z_]+\|>`)
+	openingFence = regexp.MustCompile("^(`{3,}|~{3,})\\s*([\\w+-]*)\\s*$")
+	label        = regexp.MustCompile(`(?i)^[*_#\s]*((suggested|proposed|generated|git)\s+)?commit(\s+message)?[*_\s]*:[*_\s]*`)
+	preamble     = regexp.MustCompile(`(?i)^(here\s+is|here's|here\s+are|sure|certainly|okay|ok|of course|absolutely|based on)\b.*[:.!]\s*$`)
+	postamble    = regexp.MustCompile(`(?i)^(let me know|i hope|hope this|feel free|this commit message|explanation:|\*\*explanation)`)
+	zeroWidth    = strings.NewReplacer("\u200b", "", "\u200c", "", "\u200d", "", "\ufeff", "")
+)
+
+// Sanitize removes what models wrap around the commit message itself:
+// thinking sections, a preamble such as "Here is your commit message:", code
+// fences around the whole answer, closing remarks and quotes. Whitespace is
+// then normalised as git would, so the answer is ready for the editor.
+func Sanitize(response string) string {
+	text := strings.ReplaceAll(response, "\r\n", "\n")
+	text = zeroWidth.Replace(text)
+
+	text = finalChannel.ReplaceAllString(text, "")
+	text = thinking.ReplaceAllString(text, "")
+	text = specialToken.ReplaceAllString(text, "")
+
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	lines = trimThinking(lines)
+	lines, introduced := trimPreamble(lines)
+	lines, fenced := unwrapFence(lines)
+	if introduced || fenced {
+		// closing remarks are only to be expected of an answer that
+		// introduced the message, rather than being just the message
+		lines = trimPostamble(lines)
+	}
+
+	return unquote(normalize(lines))
+}
+
+// trimThinking drops the thinking that ends with a closing tag on a line of
+// its own, the opening tag sometimes being part of the prompt template, and
+// so missing from the answer. A tag after the subject is part of the message.
+func trimThinking(lines []string) []string {
+	for i, line := range lines {
+		if subjectLike.MatchString(line) {
+			return lines
+		}
+		if thinkingEnd.MatchString(line) {
+			return lines[i+1:]
+		}
+	}
+	return lines
+}
+
+// trimPreamble drops the leading lines introducing the answer, and any label
+// in front of the subject, reporting whether there were any.
+func trimPreamble(lines []string) ([]string, bool) {
+	introduced := false
+	for len(lines) > 0 {
+		line := strings.TrimSpace(lines[0])
+		switch {
+		case line == "":
+			lines = lines[1:]
+		case preamble.MatchString(line) && introduces(line, lines[1:]):
+			lines, introduced = lines[1:], true
+		case label.MatchString(line):
+			if rest := label.ReplaceAllString(line, ""); rest != "" {
+				lines[0] = rest
+				return lines, true
+			}
+			lines, introduced = lines[1:], true
+		default:
+			return lines, introduced
+		}
+	}
+	return lines, introduced
+}
+
+// introduces reports whether a line that reads like a preamble introduces the
+// lines that follow, rather than being the subject of a message such as "OK
+// button closes the dialog.": either it ends with a colon, or what follows is
+// a code block, a label or a conventional subject.
+func introduces(line string, rest []string) bool {
+	if strings.HasSuffix(line, ":") {
+		return len(rest) > 0
+	}
+	for _, next := range rest {
+		next = strings.TrimSpace(next)
+		if next != "" {
+			return openingFence.MatchString(next) || label.MatchString(next) || subjectLike.MatchString(next)
+		}
+	}
+	return false
+}
+
+// unwrapFence returns the contents of the code block, when the answer starts
+// with one: anything after it is commentary, as a commit message cannot start
+// with code. It reports whether the block was closed.
+func unwrapFence(lines []string) ([]string, bool) {
+	if len(lines) == 0 {
+		return lines, false
+	}
+	m := openingFence.FindStringSubmatch(strings.TrimSpace(lines[0]))
+	if m == nil {
+		return lines, false
+	}
+
+	// the last closing fence, in case the message has code blocks of its own
+	for i := len(lines) - 1; i > 0; i-- {
+		if strings.TrimSpace(lines[i]) == m[1] {
+			return lines[1:i], true
+		}
+	}
+	// unterminated, as when the answer was cut short
+	return lines[1:], false
+}
+
+// trimPostamble drops the last paragraph when it is a closing remark, rather
+// than part of the message.
+func trimPostamble(lines []string) []string {
+	end := len(lines)
+	for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	start := end
+	for start > 0 && strings.TrimSpace(lines[start-1]) != "" {
+		start--
+	}
+	// never the subject, which is all there is without a blank line before
+	if start == 0 || !postamble.MatchString(strings.TrimSpace(lines[start])) {
+		return lines
+	}
+	return lines[:start]
+}
+
+// normalize trims trailing whitespace, collapses runs of blank lines and
+// removes leading and trailing blank lines.
+func normalize(lines []string) string {
+	var out []string
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\u00a0")
+		if line == "" && (len(out) == 0 || out[len(out)-1] == "") {
+			continue
+		}
+		out = append(out, line)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+// unquote removes the quotes or backticks around a single line answer.
+func unquote(text string) string {
+	if strings.Contains(text, "\n") || len(text) < 2 {
+		return text
+	}
+	for _, quote := range []string{`"`, "'", "`", "“"} {
+		closing := quote
+		if quote == "“" {
+			closing = "”"
+		}
+		inner, ok := strings.CutPrefix(text, quote)
+		if !ok {
+			continue
+		}
+		if inner, ok = strings.CutSuffix(inner, closing); ok && !strings.Contains(inner, closing) {
+			return strings.TrimSpace(inner)
+		}
+	}
+	return text
+}
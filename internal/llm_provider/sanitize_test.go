@@ -0,0 +1,167 @@
+package llmprovider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		expected string
+	}{
+		{
+			name:     "AlreadyClean",
+			response: "feat: add sanitizer\n\nStrip the noise around the message.",
+			expected: "feat: add sanitizer\n\nStrip the noise around the message.",
+		},
+		{
+			name:     "ThinkingBlock",
+			response: "<think>\nThe diff adds a parser, so this is a feature.\n</think>\n\nfeat: add parser",
+			expected: "feat: add parser",
+		},
+		{
+			name:     "EmptyThinkingBlock",
+			response: "<think>\n\n</think>\n\nfix: handle empty diffs",
+			expected: "fix: handle empty diffs",
+		},
+		{
+			name:     "ThinkingWithoutOpeningTag",
+			response: "Okay, the user changed the config loader.\n</think>\nrefactor: simplify config loading",
+			expected: "refactor: simplify config loading",
+		},
+		{
+			name:     "HarmonyChannels",
+			response: "<|channel|>analysis<|message|>Looks like docs.<|end|><|start|>assistant<|channel|>final<|message|>docs: explain flags",
+			expected: "docs: explain flags",
+		},
+		{
+			name:     "PreambleAndFence",
+			response: "Here is a concise commit message for the changes:\n\n```\nfeat(ui): add preview\n\nRender the message with glamour.\n```",
+			expected: "feat(ui): add preview\n\nRender the message with glamour.",
+		},
+		{
+			name:     "FenceWithInfoString",
+			response: "```markdown\nchore: bump deps\n```",
+			expected: "chore: bump deps",
+		},
+		{
+			name:     "FenceKeepsCodeBlocksInBody",
+			response: "```\nfeat: add flag\n\nUsage:\n\n```sh\ngit commit-summary --lang German\n```\n```",
+			expected: "feat: add flag\n\nUsage:\n\n```sh\ngit commit-summary --lang German\n```",
+		},
+		{
+			name:     "UnterminatedFence",
+			response: "```\nfix: close file handles",
+			expected: "fix: close file handles",
+		},
+		{
+			name:     "Postamble",
+			response: "Sure! Here's a commit message:\n\nfix(git): quote paths\n\nPaths with spaces were split.\n\nLet me know if you'd like any changes.",
+			expected: "fix(git): quote paths\n\nPaths with spaces were split.",
+		},
+		{
+			name:     "PreambleWithoutColon",
+			response: "Sure, here it is.\n\nfeat: add retries\n\nRetry failed requests.",
+			expected: "feat: add retries\n\nRetry failed requests.",
+		},
+		{
+			name:     "SubjectLikePreambleKept",
+			response: "Based on review, reject empty names.\n\nAn empty name made the lookup panic.",
+			expected: "Based on review, reject empty names.\n\nAn empty name made the lookup panic.",
+		},
+		{
+			name:     "SubjectStartingWithOKKept",
+			response: "OK button closes the dialog.\n\nIt used to submit the form.",
+			expected: "OK button closes the dialog.\n\nIt used to submit the form.",
+		},
+		{
+			name:     "ExplanationAfterFence",
+			response: "```\nperf: cache responses\n```\n\nThis commit message follows the conventional commits format.",
+			expected: "perf: cache responses",
+		},
+		{
+			name:     "NoteInBodyKept",
+			response: "feat: add migration\n\nNote: run the migration before deploying.\n\nRefs: #42",
+			expected: "feat: add migration\n\nNote: run the migration before deploying.\n\nRefs: #42",
+		},
+		{
+			name:     "ExplanationInBodyKept",
+			response: "docs: describe the cache\n\nExplanation: the cache is keyed by the diff.\n\nSigned-off-by: A U Thor <author@example.com>",
+			expected: "docs: describe the cache\n\nExplanation: the cache is keyed by the diff.\n\nSigned-off-by: A U Thor <author@example.com>",
+		},
+		{
+			name:     "PostambleOnlyAsLastParagraph",
+			response: "Here's the commit message:\n\nfix: retry requests\n\nThis commit message body explains why.\n\nRefs: #7",
+			expected: "fix: retry requests\n\nThis commit message body explains why.\n\nRefs: #7",
+		},
+		{
+			name:     "PostambleAfterTrailers",
+			response: "Here's the commit message:\n\nfix: retry requests\n\nRefs: #7\n\nI hope this helps!",
+			expected: "fix: retry requests\n\nRefs: #7",
+		},
+		{
+			name:     "PostambleWithoutIntroductionKept",
+			response: "chore: tidy\n\nLet me know if anything breaks after this.",
+			expected: "chore: tidy\n\nLet me know if anything breaks after this.",
+		},
+		{
+			name:     "ClosingTagInSubjectKept",
+			response: "fix: parse </think> tags in templates\n\nBody",
+			expected: "fix: parse </think> tags in templates\n\nBody",
+		},
+		{
+			name:     "ClosingTagAfterSubjectKept",
+			response: "fix: strip reasoning\n\nModels end it with\n</think>\nwhen the opening tag is in the template.",
+			expected: "fix: strip reasoning\n\nModels end it with\n</think>\nwhen the opening tag is in the template.",
+		},
+		{
+			name:     "BoldLabel",
+			response: "**Commit message:**\n\ntest: cover sanitizer",
+			expected: "test: cover sanitizer",
+		},
+		{
+			name:     "InlineLabel",
+			response: "Commit message: build: drop stringwrap",
+			expected: "build: drop stringwrap",
+		},
+		{
+			name:     "Quoted",
+			response: "\"style: gofmt imports\"",
+			expected: "style: gofmt imports",
+		},
+		{
+			name:     "Backticks",
+			response: "`ci: run vet`",
+			expected: "ci: run vet",
+		},
+		{
+			name:     "QuotesWithinMessageKept",
+			response: "fix: handle \"quoted\" paths",
+			expected: "fix: handle \"quoted\" paths",
+		},
+		{
+			name:     "Whitespace",
+			response: "\ufefffeat: add thing  \r\n\r\n\r\n\r\nBody\u200b line\t\r\n\n\n",
+			expected: "feat: add thing\n\nBody line",
+		},
+		{
+			name:     "SubjectLookingLikePreamble",
+			response: "ok: handle empty diffs",
+			expected: "ok: handle empty diffs",
+		},
+		{
+			name:     "Empty",
+			response: "<think>nothing to see</think>",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Sanitize(tt.response))
+		})
+	}
+}
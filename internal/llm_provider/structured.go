@@ -0,0 +1,85 @@
+package llmprovider
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/rm-hull/git-commit-summary/internal/message"
+)
+
+// StructuredProvider is implemented by the providers that can be made to
+// answer with a commit message in parts, following the schema below, rather
+// than with free text that has to be picked apart.
+type StructuredProvider interface {
+	Provider
+	CallStructured(ctx context.Context, systemPrompt string, messages []Message) (message.Structured, error)
+}
+
+var (
+	_ StructuredProvider = (*GoogleProvider)(nil)
+	_ StructuredProvider = (*OpenAiProvider)(nil)
+)
+
+// errStructuredUnsupported marks the errors of a structured call that show the
+// server, or the model, has no support for answering to a schema.
+var errStructuredUnsupported = errors.New("structured output is not supported")
+
+// unsupportedPattern matches the complaints of servers about the parameters
+// used to ask for structured output.
+var unsupportedPattern = regexp.MustCompile(`(?i)response_?format|json_?schema|response_?schema|response_?mime_?type|json mode|structured output`)
+
+// markUnsupported marks the error of a structured call as unsupported, when it
+// complains about the parameters asking for structured output.
+func markUnsupported(err error) error {
+	if err != nil && unsupportedPattern.MatchString(err.Error()) {
+		return errors.Mark(err, errStructuredUnsupported)
+	}
+	return err
+}
+
+// parseStructured parses a structured answer. An answer that does not follow
+// the schema is taken to mean that the schema was ignored.
+func parseStructured(text string) (message.Structured, error) {
+	msg, err := message.ParseStructured(text)
+	if err != nil {
+		return msg, errors.Mark(err, errStructuredUnsupported)
+	}
+	return msg, nil
+}
+
+type schemaField struct {
+	name        string
+	kind        string // string or array (of strings)
+	description string
+	enum        []string
+}
+
+// commitSchema describes message.Structured to the model.
+var commitSchema = []schemaField{
+	{name: "type", kind: "string", description: "The conventional commit type of the change", enum: message.Types},
+	{name: "scope", kind: "string", description: "The area of the code base affected, e.g. a package name, or empty"},
+	{name: "subject", kind: "string", description: "A short imperative summary of the change, without the type or scope"},
+	{name: "body", kind: "string", description: "A longer description of what changed and why, or empty"},
+	{name: "breaking", kind: "string", description: "What breaks for users of the code, or empty if nothing does"},
+	{name: "footers", kind: "array", description: "Trailer lines such as `Refs: #123`, usually none"},
+}
+
+// Generate asks the provider for a commit message: in parts when structured
+// output is requested and supported, or else as free text. Should the
+// structured call fail for want of support, as it does with OpenAI compatible
+// servers that have no support for schemas, then free text is asked for
+// instead. Any other failure is returned as it is.
+func Generate(ctx context.Context, provider Provider, structured bool, systemPrompt string, messages []Message) (string, error) {
+	if sp, ok := provider.(StructuredProvider); ok && structured {
+		msg, err := sp.CallStructured(ctx, systemPrompt, messages)
+		if err == nil {
+			return msg.String(), nil
+		}
+		if ctx.Err() != nil || !errors.Is(err, errStructuredUnsupported) {
+			return "", err
+		}
+	}
+	return provider.Chat(ctx, systemPrompt, messages)
+}
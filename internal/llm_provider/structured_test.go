@@ -0,0 +1,108 @@
+package llmprovider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rm-hull/git-commit-summary/internal/message"
+)
+
+type fakeProvider struct {
+	calls int
+}
+
+func (p *fakeProvider) Call(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	p.calls++
+	return "free text", nil
+}
+
+func (p *fakeProvider) Chat(ctx context.Context, systemPrompt string, messages []Message) (string, error) {
+	p.calls++
+	return "free text", nil
+}
+
+func (p *fakeProvider) Model() string {
+	return "fake"
+}
+
+type fakeStructuredProvider struct {
+	fakeProvider
+	structured message.Structured
+	err        error
+}
+
+func (p *fakeStructuredProvider) CallStructured(ctx context.Context, systemPrompt string, messages []Message) (message.Structured, error) {
+	return p.structured, p.err
+}
+
+func TestGenerate(t *testing.T) {
+	ctx := context.Background()
+	prompt := []Message{{Role: RoleUser, Content: "prompt"}}
+
+	t.Run("Structured", func(t *testing.T) {
+		provider := &fakeStructuredProvider{structured: message.Structured{Type: "feat", Subject: "add schema"}}
+		resp, err := Generate(ctx, provider, true, "", prompt)
+		assert.NoError(t, err)
+		assert.Equal(t, "feat: add schema", resp)
+		assert.Zero(t, provider.calls)
+	})
+
+	t.Run("NotRequested", func(t *testing.T) {
+		provider := &fakeStructuredProvider{structured: message.Structured{Type: "feat", Subject: "add schema"}}
+		resp, err := Generate(ctx, provider, false, "", prompt)
+		assert.NoError(t, err)
+		assert.Equal(t, "free text", resp)
+	})
+
+	t.Run("NotSupported", func(t *testing.T) {
+		resp, err := Generate(ctx, &fakeProvider{}, true, "", prompt)
+		assert.NoError(t, err)
+		assert.Equal(t, "free text", resp)
+	})
+
+	t.Run("FallsBackWhenUnsupported", func(t *testing.T) {
+		provider := &fakeStructuredProvider{err: markUnsupported(errors.New("response_format is not supported"))}
+		resp, err := Generate(ctx, provider, true, "", prompt)
+		assert.NoError(t, err)
+		assert.Equal(t, "free text", resp)
+		assert.Equal(t, 1, provider.calls)
+	})
+
+	t.Run("FallsBackWhenSchemaIgnored", func(t *testing.T) {
+		_, parseErr := parseStructured("feat: not json")
+		provider := &fakeStructuredProvider{err: parseErr}
+		resp, err := Generate(ctx, provider, true, "", prompt)
+		assert.NoError(t, err)
+		assert.Equal(t, "free text", resp)
+	})
+
+	t.Run("ReturnsOtherErrors", func(t *testing.T) {
+		provider := &fakeStructuredProvider{err: markUnsupported(errors.New("401 Unauthorized: invalid API key"))}
+		_, err := Generate(ctx, provider, true, "", prompt)
+		assert.ErrorContains(t, err, "invalid API key")
+		assert.Zero(t, provider.calls)
+	})
+
+	t.Run("ReturnsErrorOnceCancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		provider := &fakeStructuredProvider{err: markUnsupported(errors.New("response_format: context canceled"))}
+		_, err := Generate(ctx, provider, true, "", prompt)
+		assert.Error(t, err)
+		assert.Zero(t, provider.calls)
+	})
+}
+
+func TestSchemas(t *testing.T) {
+	openAI := openAISchema()
+	assert.Equal(t, false, openAI["additionalProperties"])
+	assert.Len(t, openAI["required"], len(commitSchema))
+	assert.Contains(t, openAI["properties"], "footers")
+
+	gemini := geminiSchema()
+	assert.Equal(t, []string{"type", "scope", "subject", "body", "breaking", "footers"}, gemini.PropertyOrdering)
+	assert.Equal(t, message.Types, gemini.Properties["type"].Enum)
+}
@@ -29,6 +29,10 @@ func (provider *TestDummyProvider) Call(ctx context.Context, systemPrompt, userP
 	return stockResponse, nil
 }
 
+func (provider *TestDummyProvider) Chat(ctx context.Context, systemPrompt string, messages []Message) (string, error) {
+	return provider.Call(ctx, systemPrompt, messages[len(messages)-1].Content)
+}
+
 func (provider *TestDummyProvider) Model() string {
 	return "test-model"
 }
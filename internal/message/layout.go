@@ -0,0 +1,192 @@
+package message
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+const (
+	DefaultWidth        = 72
+	DefaultSubjectWidth = 50
+)
+
+var (
+	fence      = regexp.MustCompile("^ {0,3}(```|~~~)")
+	listMarker = regexp.MustCompile(`^(\s*)([-*+]|[0-9]+[.)])(\s+)`)
+	heading    = regexp.MustCompile(`^ {0,3}#{1,6}(\s|$)`)
+	rule       = regexp.MustCompile(`^ {0,3}([-*_])(\s*([-*_]))*\s*$`)
+)
+
+// Layout lays out commit messages: the subject is kept to a single line, and
+// the prose paragraphs and list items of the body are wrapped, whereas fenced
+// code blocks, indented code, tables, headings, quotes and trailers are left
+// verbatim. The zero value uses the default widths.
+type Layout struct {
+	Width        int // the body is wrapped at this many columns
+	SubjectWidth int // a subject wider than this many columns is flagged
+	Disabled     bool
+}
+
+// Apply lays out the message.
+func (l Layout) Apply(text string) string {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	if l.Disabled || text == "" {
+		return text
+	}
+
+	width := l.Width
+	if width <= 0 {
+		width = DefaultWidth
+	}
+	body, trailers := SplitTrailers(text)
+	subject, body, _ := strings.Cut(body, "\n")
+
+	var sb strings.Builder
+	sb.WriteString(strings.TrimSpace(subject))
+	if body = strings.Trim(body, "\n"); body != "" {
+		sb.WriteString("\n\n")
+		sb.WriteString(layoutBody(body, width))
+	}
+	return AppendTrailers(sb.String(), trailers...)
+}
+
+// LongSubject reports whether the subject of the message is wider than it
+// should be, and how wide that is. It is left for the author to shorten, as
+// only they know what may be left out.
+func (l Layout) LongSubject(text string) (int, bool) {
+	limit := l.SubjectWidth
+	if limit <= 0 {
+		limit = DefaultSubjectWidth
+	}
+	subject, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	width := runewidth.StringWidth(subject)
+	return width, width > limit
+}
+
+func layoutBody(body string, width int) string {
+	var out []string
+	lines := strings.Split(body, "\n")
+	// an indented line straight after a list item is a nested item, but
+	// anywhere else is code, which may well look like a list item itself
+	afterListItem := false
+
+	for i := 0; i < len(lines); {
+		line := strings.TrimRight(lines[i], " \t")
+		if line == "" {
+			// collapse runs of blank lines into one
+			if len(out) > 0 && out[len(out)-1] != "" {
+				out = append(out, "")
+			}
+			afterListItem = false
+			i++
+			continue
+		}
+
+		inList := afterListItem
+		afterListItem = false
+
+		switch {
+		case fence.MatchString(line):
+			marker := fence.FindStringSubmatch(line)[1]
+			out = append(out, lines[i])
+			for i++; i < len(lines); i++ {
+				out = append(out, lines[i])
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), marker) {
+					i++
+					break
+				}
+			}
+
+		case verbatim(line):
+			out = append(out, lines[i])
+			i++
+
+		case indentedCode(line) && !inList:
+			out = append(out, lines[i])
+			i++
+
+		case listMarker.MatchString(line):
+			lead := listMarker.FindString(line)
+			indent := strings.Repeat(" ", runewidth.StringWidth(lead))
+			text, next := paragraph(lines, i, line[len(lead):], true)
+			out = append(out, hangingWrap(lead, indent, text, width)...)
+			afterListItem = true
+			i = next
+
+		case indentedCode(line):
+			out = append(out, lines[i])
+			i++
+
+		default:
+			text, next := paragraph(lines, i, line, false)
+			out = append(out, strings.Split(Wrap(text, width), "\n")...)
+			i = next
+		}
+	}
+
+	return strings.TrimRight(strings.Join(out, "\n"), "\n")
+}
+
+// paragraph joins the lines of a paragraph (or list item) starting at the
+// given line, until a blank line or a line starting another block. Lines that
+// end in a Markdown hard line break are kept apart.
+func paragraph(lines []string, start int, first string, listItem bool) (string, int) {
+	var sb strings.Builder
+	sb.WriteString(strings.TrimSpace(first))
+	breakAfter := hardBreak(lines[start])
+
+	i := start + 1
+	for ; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+		if line == "" || fence.MatchString(line) || verbatim(line) || listMarker.MatchString(line) {
+			break
+		}
+		if listItem && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			// an item's continuation lines are indented
+			break
+		}
+		if breakAfter {
+			sb.WriteString("\n")
+		} else {
+			sb.WriteString(" ")
+		}
+		sb.WriteString(strings.TrimSpace(line))
+		breakAfter = hardBreak(lines[i])
+	}
+	return sb.String(), i
+}
+
+// verbatim reports whether the line is part of a table, quote, heading or
+// thematic break, none of which may be reflowed.
+func verbatim(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "|") || strings.HasPrefix(trimmed, ">") ||
+		heading.MatchString(line) || rule.MatchString(line) && len(trimmed) >= 3
+}
+
+// indentedCode reports whether the line, not being part of a paragraph or
+// list item, is an indented code block.
+func indentedCode(line string) bool {
+	return strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")
+}
+
+func hardBreak(line string) bool {
+	return strings.HasSuffix(line, "  ") || strings.HasSuffix(line, "\\")
+}
+
+// hangingWrap wraps the text, prefixing the first line with lead and the
+// following lines with indent.
+func hangingWrap(lead, indent, text string, width int) []string {
+	available := max(width-runewidth.StringWidth(indent), 1)
+	wrapped := strings.Split(Wrap(text, available), "\n")
+	for i := range wrapped {
+		if i == 0 {
+			wrapped[i] = lead + wrapped[i]
+		} else {
+			wrapped[i] = indent + wrapped[i]
+		}
+	}
+	return wrapped
+}
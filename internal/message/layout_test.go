@@ -0,0 +1,115 @@
+package message
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLayout(t *testing.T) {
+	prose := strings.TrimSpace(strings.Repeat("word ", 20))
+
+	tests := []struct {
+		name     string
+		layout   Layout
+		message  string
+		expected string
+	}{
+		{
+			name:     "Subject only",
+			message:  "feat: add x\n",
+			expected: "feat: add x",
+		},
+		{
+			name:    "Prose paragraphs are reflowed",
+			message: "feat: add x\n\n" + "word word\nword " + strings.Repeat("word ", 17) + "\n\n\n\nAnother paragraph.",
+			expected: "feat: add x\n\n" +
+				strings.TrimSpace(strings.Repeat("word ", 14)) + "\n" +
+				strings.TrimSpace(strings.Repeat("word ", 6)) + "\n\n" +
+				"Another paragraph.",
+		},
+		{
+			name:    "List items wrap with a hanging indent",
+			message: "feat: add x\n\n- " + prose + "\n-   second\n    continued\n1. third",
+			expected: "feat: add x\n\n" +
+				"- " + strings.TrimSpace(strings.Repeat("word ", 14)) + "\n" +
+				"  " + strings.TrimSpace(strings.Repeat("word ", 6)) + "\n" +
+				"-   second continued\n" +
+				"1. third",
+		},
+		{
+			name:     "Fenced code blocks are verbatim",
+			message:  "fix: y\n\n```go\nfunc main() {\n\n\n\tfmt.Println(\"" + prose + "\")\n}\n```",
+			expected: "fix: y\n\n```go\nfunc main() {\n\n\n\tfmt.Println(\"" + prose + "\")\n}\n```",
+		},
+		{
+			name:     "Indented code that looks like a list is verbatim",
+			message:  "ci: add a build step\n\nThe workflow gains:\n\n    - name: build\n      run: make " + prose + "\n    1. step",
+			expected: "ci: add a build step\n\nThe workflow gains:\n\n    - name: build\n      run: make " + prose + "\n    1. step",
+		},
+		{
+			name:    "Nested list items still wrap",
+			message: "feat: add x\n\n- first\n    - " + prose,
+			expected: "feat: add x\n\n- first\n    - " + strings.TrimSpace(strings.Repeat("word ", 13)) + "\n" +
+				"      " + strings.TrimSpace(strings.Repeat("word ", 7)),
+		},
+		{
+			name:     "Tables, headings and quotes are verbatim",
+			message:  "docs: z\n\n## Heading\n| a | b |\n|---|---|\n| " + prose + " | c |\n> " + prose,
+			expected: "docs: z\n\n## Heading\n| a | b |\n|---|---|\n| " + prose + " | c |\n> " + prose,
+		},
+		{
+			name:     "URLs are not broken",
+			message:  "docs: z\n\nSee https://example.com/" + strings.Repeat("x", 80) + " for details.",
+			expected: "docs: z\n\nSee\nhttps://example.com/" + strings.Repeat("x", 80) + "\nfor details.",
+		},
+		{
+			name:     "Trailers are verbatim",
+			message:  "feat: pair\n\nCo-authored-by: " + prose + " <a@example.com>",
+			expected: "feat: pair\n\nCo-authored-by: " + prose + " <a@example.com>",
+		},
+		{
+			name:     "Long subject is kept",
+			layout:   Layout{SubjectWidth: 20},
+			message:  "feat: add a rather long subject line\n\nBody.",
+			expected: "feat: add a rather long subject line\n\nBody.",
+		},
+		{
+			name:     "Custom width",
+			layout:   Layout{Width: 20},
+			message:  "feat: add x\n\n" + prose,
+			expected: "feat: add x\n\nword word word word\nword word word word\nword word word word\nword word word word\nword word word word",
+		},
+		{
+			name:     "Disabled",
+			layout:   Layout{Disabled: true},
+			message:  "feat: add x\n\n" + prose + "\n\n\n\nend\n",
+			expected: "feat: add x\n\n" + prose + "\n\n\n\nend",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.layout.Apply(test.message))
+		})
+	}
+}
+
+func TestLayoutOfPlainCode(t *testing.T) {
+	markdown := "ci: add a build step\n\n```yaml\nsteps:\n  - name: build\n    run: make all\n  - name: test\n    run: make test\n```"
+	expected := "ci: add a build step\n\n    steps:\n      - name: build\n        run: make all\n      - name: test\n        run: make test"
+	assert.Equal(t, expected, Layout{}.Apply(ToPlain(markdown)))
+}
+
+func TestLongSubject(t *testing.T) {
+	width, long := Layout{}.LongSubject("feat: add a subject that goes on for rather too long\n\nBody.")
+	assert.True(t, long)
+	assert.Equal(t, 52, width)
+
+	_, long = Layout{}.LongSubject("feat: add a short subject\n\n" + strings.Repeat("word ", 20))
+	assert.False(t, long)
+
+	_, long = Layout{SubjectWidth: 10}.LongSubject("feat: add x")
+	assert.True(t, long)
+}
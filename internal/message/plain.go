@@ -0,0 +1,139 @@
+package message
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+const (
+	FormatMarkdown = "markdown"
+	FormatPlain    = "plain"
+)
+
+// Formats are the names of the supported message formats.
+var Formats = []string{FormatMarkdown, FormatPlain}
+
+var (
+	headingPrefix = regexp.MustCompile(`^ {0,3}#{1,6}\s+`)
+	headingSuffix = regexp.MustCompile(`\s+#+\s*$`)
+	setextRule    = regexp.MustCompile(`^ {0,3}(=+|-+)\s*$`)
+	image         = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)[^)]*\)`)
+	link          = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)[^)]*\)`)
+	strong        = regexp.MustCompile(`(\*\*|__)(\S(?:.*?\S)?)(\*\*|__)`)
+	strike        = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+	emphasis      = regexp.MustCompile(`(^|[^\w*])([*_])(\S(?:[^*_]*?\S)?)([*_])([^\w*]|$)`)
+	escaped       = regexp.MustCompile(`\\([\\` + "`" + `*_{}\[\]()#+\-.!|~>])`)
+	inlineCode    = regexp.MustCompile("`+[^`]*`+")
+)
+
+// escapeBase is the start of the private use area that escaped characters are
+// moved into while the inline Markdown is removed.
+const escapeBase = 0xE000
+
+// ValidateFormat checks that the message format is one of those supported.
+func ValidateFormat(format string) error {
+	switch format {
+	case "", FormatMarkdown, FormatPlain:
+		return nil
+	default:
+		return errors.Newf("unknown format %q, expected one of: %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// ToPlain converts a Markdown message to plain text, for repositories whose
+// history is read in plain terminals: headings become plain lines, emphasis
+// markers are removed, links are written out, and fenced code blocks become
+// indented ones. Bullets, inline code and indentation are kept.
+func ToPlain(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	var out []string
+
+	separate := func() {
+		if len(out) > 0 && out[len(out)-1] != "" {
+			out = append(out, "")
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if m := fence.FindStringSubmatch(line); m != nil {
+			separate()
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), m[1]); i++ {
+				if strings.TrimSpace(lines[i]) == "" {
+					out = append(out, "")
+				} else {
+					out = append(out, "    "+lines[i])
+				}
+			}
+			out = append(out, "")
+			continue
+		}
+
+		if headingPrefix.MatchString(line) {
+			separate()
+			out = append(out, plainInline(headingSuffix.ReplaceAllString(headingPrefix.ReplaceAllString(line, ""), "")), "")
+			continue
+		}
+
+		if setextRule.MatchString(line) && len(out) > 0 && out[len(out)-1] != "" {
+			// the underline of the heading on the previous line
+			out = append(out, "")
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" && len(out) > 0 && out[len(out)-1] == "" {
+			continue
+		}
+		out = append(out, plainInline(strings.TrimRight(line, " ")))
+	}
+
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+// plainInline removes the inline Markdown from a line, leaving any inline
+// code untouched.
+func plainInline(line string) string {
+	var sb strings.Builder
+	last := 0
+	for _, span := range inlineCode.FindAllStringIndex(line, -1) {
+		sb.WriteString(plainText(line[last:span[0]]))
+		sb.WriteString(line[span[0]:span[1]])
+		last = span[1]
+	}
+	sb.WriteString(plainText(line[last:]))
+	return sb.String()
+}
+
+func plainText(text string) string {
+	// hide escaped characters from the patterns below, until they are unescaped
+	text = escaped.ReplaceAllStringFunc(text, func(match string) string {
+		return string(rune(escapeBase + int(match[1])))
+	})
+
+	text = image.ReplaceAllString(text, "$1 ($2)")
+	text = link.ReplaceAllStringFunc(text, func(match string) string {
+		m := link.FindStringSubmatch(match)
+		if m[1] == m[2] {
+			return m[2]
+		}
+		return m[1] + " (" + m[2] + ")"
+	})
+	text = strong.ReplaceAllString(text, "$2")
+	text = strike.ReplaceAllString(text, "$1")
+	// each match takes the boundary after its span, which hides the start of
+	// a span right after it, so the spans are stripped until none are left
+	for stripped := ""; stripped != text; {
+		stripped = text
+		text = emphasis.ReplaceAllString(text, "$1$3$5")
+	}
+
+	return strings.Map(func(r rune) rune {
+		if r >= escapeBase && r < escapeBase+128 {
+			return r - escapeBase
+		}
+		return r
+	}, text)
+}
@@ -0,0 +1,50 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToPlain(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		expected string
+	}{
+		{"Plain text is unchanged", "feat: add x\n\nSome details.", "feat: add x\n\nSome details."},
+		{"Emphasis", "fix: **really** fix _it_, *now* and ~~then~~", "fix: really fix it, now and then"},
+		{"Adjacent emphasis", "fix: _a_ _b_ and *c* *d*", "fix: a b and c d"},
+		{"Identifiers are not emphasis", "refactor: rename snake_case_name and 2*3*4", "refactor: rename snake_case_name and 2*3*4"},
+		{"Inline code is kept", "docs: explain `**kwargs` and `a_b_`", "docs: explain `**kwargs` and `a_b_`"},
+		{"Links", "docs: see [the docs](https://example.com) or [https://x.io](https://x.io)", "docs: see the docs (https://example.com) or https://x.io"},
+		{"Escapes", `fix: handle \*stars\*`, "fix: handle *stars*"},
+		{
+			name:     "Headings",
+			markdown: "feat: add x\n\n## Summary\nDoes things.\n\nDetails\n-------\nMore.",
+			expected: "feat: add x\n\nSummary\n\nDoes things.\n\nDetails\n\nMore.",
+		},
+		{
+			name:     "Bullets are kept",
+			markdown: "feat: add x\n\n* **First** item\n- second item\n  continued",
+			expected: "feat: add x\n\n* First item\n- second item\n  continued",
+		},
+		{
+			name:     "Fenced code becomes indented",
+			markdown: "fix: y\nExample:\n```go\nfunc main() {\n\tprintln(\"**hi**\")\n}\n```\nDone.",
+			expected: "fix: y\nExample:\n\n    func main() {\n    \tprintln(\"**hi**\")\n    }\n\nDone.",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, ToPlain(test.markdown))
+		})
+	}
+}
+
+func TestValidateFormat(t *testing.T) {
+	assert.NoError(t, ValidateFormat(""))
+	assert.NoError(t, ValidateFormat(FormatPlain))
+	assert.ErrorContains(t, ValidateFormat("html"), `unknown format "html"`)
+}
@@ -0,0 +1,123 @@
+package message
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+// genericDirs are directory names that say nothing about what changed, and so
+// are never inferred as a scope.
+var genericDirs = []string{"src", "lib", "pkg", "internal", "cmd", "app", "source", "main"}
+
+// ScopeRule maps the paths matching a glob to a scope.
+type ScopeRule struct {
+	Pattern string // e.g. services/api/**, where ** matches any number of directories
+	Scope   string
+}
+
+// ScopeMap maps paths to the conventional commit scopes expected for them,
+// the first matching rule winning.
+type ScopeMap []ScopeRule
+
+// ParseScopeMap parses rules written as `glob=scope`, e.g.
+// `services/api/**=api`. A glob without any wildcards matches the directory of
+// that name.
+func ParseScopeMap(entries ...string) (ScopeMap, error) {
+	var rules ScopeMap
+	for _, entry := range entries {
+		pattern, scope, ok := strings.Cut(entry, "=")
+		pattern, scope = strings.Trim(pattern, "/"), strings.TrimSpace(scope)
+		if !ok || pattern == "" || scope == "" {
+			return nil, errors.Newf("expected glob=scope, got %q", entry)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, errors.Wrapf(err, "invalid glob %q", pattern)
+		}
+		rules = append(rules, ScopeRule{Pattern: pattern, Scope: scope})
+	}
+	return rules, nil
+}
+
+// Scope infers the scope of a change to the given paths. The paths matching
+// a rule must all agree on their scope, in which case mapped is true; paths
+// matching no rule are ignored. When no path matches a rule, the scope is
+// taken from the closest common directory of the paths, skipping directories
+// such as src or internal, or is blank when that is the repository root.
+func (m ScopeMap) Scope(paths []string) (scope string, mapped bool) {
+	var scopes []string
+	for _, p := range paths {
+		for _, rule := range m {
+			if matchGlob(rule.Pattern, p) {
+				if !slices.Contains(scopes, rule.Scope) {
+					scopes = append(scopes, rule.Scope)
+				}
+				break
+			}
+		}
+	}
+
+	switch {
+	case len(scopes) == 1:
+		return scopes[0], true
+	case len(scopes) > 1:
+		// a change spanning several scopes has none
+		return "", true
+	default:
+		return commonDirScope(paths), false
+	}
+}
+
+func commonDirScope(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+
+	common := strings.Split(path.Dir(paths[0]), "/")
+	for _, p := range paths[1:] {
+		dirs := strings.Split(path.Dir(p), "/")
+		n := 0
+		for n < len(common) && n < len(dirs) && common[n] == dirs[n] {
+			n++
+		}
+		common = common[:n]
+	}
+
+	// the deepest directory that is not generic, e.g. api for services/api/src
+	for i := len(common) - 1; i >= 0; i-- {
+		if dir := common[i]; dir != "." && dir != "" && !slices.Contains(genericDirs, dir) {
+			return dir
+		}
+	}
+	return ""
+}
+
+// matchGlob reports whether the path matches the pattern, in which `**`
+// matches any number of directories.
+func matchGlob(pattern, name string) bool {
+	if !strings.ContainsAny(pattern, "*?[") {
+		return name == pattern || strings.HasPrefix(name, pattern+"/")
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	ok, _ := path.Match(pattern[0], name[0])
+	return ok && matchSegments(pattern[1:], name[1:])
+}
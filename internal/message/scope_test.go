@@ -0,0 +1,61 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseScopeMap(t *testing.T) {
+	rules, err := ParseScopeMap("services/api/**=api", "web/=web", "infra/*.tf=infra")
+	assert.NoError(t, err)
+	assert.Equal(t, ScopeMap{
+		{Pattern: "services/api/**", Scope: "api"},
+		{Pattern: "web", Scope: "web"},
+		{Pattern: "infra/*.tf", Scope: "infra"},
+	}, rules)
+
+	rules, err = ParseScopeMap()
+	assert.NoError(t, err)
+	assert.Empty(t, rules)
+
+	_, err = ParseScopeMap("web")
+	assert.EqualError(t, err, `expected glob=scope, got "web"`)
+
+	_, err = ParseScopeMap("[web=web")
+	assert.ErrorContains(t, err, `invalid glob "[web"`)
+}
+
+func TestScopeMap_Scope(t *testing.T) {
+	rules := ScopeMap{
+		{Pattern: "services/api/**", Scope: "api"},
+		{Pattern: "web", Scope: "web"},
+		{Pattern: "**/*.tf", Scope: "infra"},
+	}
+
+	tests := []struct {
+		name   string
+		paths  []string
+		scope  string
+		mapped bool
+	}{
+		{"Mapped", []string{"services/api/main.go", "services/api/handlers/user.go"}, "api", true},
+		{"DirectoryPrefix", []string{"web/src/index.ts"}, "web", true},
+		{"DoubleStarAnywhere", []string{"deploy/prod/main.tf", "main.tf"}, "infra", true},
+		{"IgnoresUnmapped", []string{"web/index.ts", "go.sum"}, "web", true},
+		{"SeveralScopes", []string{"web/index.ts", "services/api/main.go"}, "", true},
+		{"CommonDirectory", []string{"internal/ui/model.go", "internal/ui/view.go"}, "ui", false},
+		{"SkipsGenericDirectories", []string{"tools/lint/src/a.go", "tools/lint/src/b/c.go"}, "lint", false},
+		{"OnlyGeneric", []string{"internal/ui/model.go", "internal/git/client.go"}, "", false},
+		{"Root", []string{"README.md", "internal/ui/model.go"}, "", false},
+		{"None", nil, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scope, mapped := rules.Scope(tt.paths)
+			assert.Equal(t, tt.scope, scope)
+			assert.Equal(t, tt.mapped, mapped)
+		})
+	}
+}
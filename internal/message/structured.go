@@ -0,0 +1,75 @@
+package message
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+// BreakingChange is the footer describing a breaking change, spelled with a
+// hyphen (as conventional commits allow) so that git reads it as a trailer.
+const BreakingChange = "BREAKING-CHANGE"
+
+// Structured is a commit message in parts, as returned by the providers that
+// support structured output.
+type Structured struct {
+	Type     string   `json:"type"`
+	Scope    string   `json:"scope"`
+	Subject  string   `json:"subject"`
+	Body     string   `json:"body"`
+	Breaking string   `json:"breaking"` // what breaks, empty if nothing does
+	Footers  []string `json:"footers"`  // `Key: value` lines
+}
+
+// ParseStructured parses a structured message from the provider's JSON.
+func ParseStructured(text string) (Structured, error) {
+	var s Structured
+	if err := json.Unmarshal([]byte(text), &s); err != nil {
+		return s, errors.Wrap(err, "failed to parse structured message")
+	}
+	if strings.TrimSpace(s.Subject) == "" {
+		return s, errors.New("structured message has no subject")
+	}
+	return s, nil
+}
+
+// String assembles the message as a conventional commit, for the style to
+// then rewrite. Footers that are not `Key: value` lines are dropped.
+func (s Structured) String() string {
+	subject := Subject{
+		Type:        strings.ToLower(strings.TrimSpace(s.Type)),
+		Scope:       strings.TrimSpace(s.Scope),
+		Breaking:    strings.TrimSpace(s.Breaking) != "",
+		Description: strings.TrimSpace(s.Subject),
+	}
+
+	// a type is not made up for a subject without one, as by the style
+	line := subject.Description
+	if subject.Type != "" {
+		line = subject.Type
+		if subject.Scope != "" {
+			line += "(" + subject.Scope + ")"
+		}
+		if subject.Breaking {
+			line += "!"
+		}
+		line += ": " + subject.Description
+	}
+
+	var trailers []Trailer
+	if subject.Breaking {
+		trailers = append(trailers, Trailer{Key: BreakingChange, Value: strings.TrimSpace(s.Breaking)})
+	}
+	for _, footer := range s.Footers {
+		if trailer, ok := ParseTrailer(footer); ok {
+			trailers = append(trailers, trailer)
+		}
+	}
+
+	text := line
+	if body := strings.TrimSpace(s.Body); body != "" {
+		text += "\n\n" + body
+	}
+	return AppendTrailers(text, trailers...)
+}
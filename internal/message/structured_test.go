@@ -0,0 +1,71 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStructured(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		s, err := ParseStructured(`{"type": "feat", "scope": "ui", "subject": "add preview", "body": "", "breaking": "", "footers": []}`)
+		assert.NoError(t, err)
+		assert.Equal(t, Structured{Type: "feat", Scope: "ui", Subject: "add preview", Footers: []string{}}, s)
+	})
+
+	t.Run("InvalidJSON", func(t *testing.T) {
+		_, err := ParseStructured("feat: add preview")
+		assert.ErrorContains(t, err, "failed to parse structured message")
+	})
+
+	t.Run("NoSubject", func(t *testing.T) {
+		_, err := ParseStructured(`{"type": "feat"}`)
+		assert.EqualError(t, err, "structured message has no subject")
+	})
+}
+
+func TestStructured_String(t *testing.T) {
+	tests := []struct {
+		name       string
+		structured Structured
+		expected   string
+	}{
+		{
+			name:       "SubjectOnly",
+			structured: Structured{Type: "fix", Subject: "handle empty diffs"},
+			expected:   "fix: handle empty diffs",
+		},
+		{
+			name:       "MissingType",
+			structured: Structured{Subject: "tidy up"},
+			expected:   "tidy up",
+		},
+		{
+			name:       "MissingTypeButBreaking",
+			structured: Structured{Scope: "ui", Subject: "drop the old theme", Breaking: "the old theme is gone"},
+			expected:   "drop the old theme\n\nBREAKING-CHANGE: the old theme is gone",
+		},
+		{
+			name:       "ScopeAndBody",
+			structured: Structured{Type: "Feat", Scope: "ui", Subject: "add preview", Body: "Render the message.\n"},
+			expected:   "feat(ui): add preview\n\nRender the message.",
+		},
+		{
+			name: "BreakingAndFooters",
+			structured: Structured{
+				Type:     "refactor",
+				Scope:    "config",
+				Subject:  "rename WRAP to WRAP_ENABLED",
+				Breaking: "WRAP is no longer read",
+				Footers:  []string{"Refs: #42", "not a trailer"},
+			},
+			expected: "refactor(config)!: rename WRAP to WRAP_ENABLED\n\nBREAKING-CHANGE: WRAP is no longer read\nRefs: #42",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.structured.String())
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package message
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/errors"
+)
+
+const (
+	Conventional = "conventional"
+	Gitmoji      = "gitmoji"
+	Plain        = "plain"
+	Custom       = "custom"
+)
+
+// Styles are the names of the supported output styles.
+var Styles = []string{Conventional, Gitmoji, Plain, Custom}
+
+// Style shapes the subject line of generated commit messages: it describes
+// the expected format to the model, and then enforces it on the response,
+// should the model have drifted from it. The zero value is the conventional
+// commits style.
+type Style struct {
+	Name     string
+	Template string // only used by the custom style
+
+	tmpl *template.Template
+}
+
+// NewStyle returns the named style, where the custom style renders the subject
+// line with the given text/template, over the fields of a Subject.
+func NewStyle(name, text string) (Style, error) {
+	switch name {
+	case "", Conventional:
+		return Style{Name: Conventional, Template: text}, nil
+	case Gitmoji, Plain:
+		return Style{Name: name, Template: text}, nil
+	case Custom:
+		if strings.TrimSpace(text) == "" {
+			return Style{}, errors.New("the custom style needs a subject template")
+		}
+		tmpl, err := template.New("subject").Option("missingkey=error").Parse(text)
+		if err != nil {
+			return Style{}, errors.Wrap(err, "failed to parse subject template")
+		}
+		if err := tmpl.Execute(&strings.Builder{}, Subject{}); err != nil {
+			return Style{}, errors.Wrap(err, "failed to render subject template")
+		}
+		return Style{Name: name, Template: text, tmpl: tmpl}, nil
+	default:
+		return Style{}, errors.Newf("unknown style %q, expected one of: %s", name, strings.Join(Styles, ", "))
+	}
+}
+
+// Instructions describes the style's subject line format, for the prompt.
+func (s Style) Instructions() string {
+	switch s.Name {
+	case Gitmoji:
+		return "Always start the first line with the single gitmoji that best describes the intention\n" +
+			"of the change (e.g. ✨ new feature, 🐛 bug fix, 📝 documentation, ♻️ refactor, ✅ tests,\n" +
+			"⚡️ performance, 🎨 formatting, 🔧 configuration), followed by a capitalized summary,\n" +
+			"e.g. `✨ Add file selection`."
+	case Plain:
+		return "Write the first line as a plain, capitalized summary in the imperative mood, without\n" +
+			"any type, emoji or other prefix, e.g. `Add file selection`."
+	case Custom:
+		var sb strings.Builder
+		_ = s.tmpl.Execute(&sb, Subject{
+			Type:        "<type>",
+			Scope:       "<scope>",
+			Emoji:       "<gitmoji>",
+			Ticket:      "<ticket>",
+			Description: "<summary>",
+		})
+		return "Always write the first line in exactly this format: `" + sb.String() + "`,\n" +
+			"where <type> is one of: " + strings.Join(Types, ", ") + ", and <scope> is the area\n" +
+			"of the code that was changed."
+	default:
+		return "Always start the first line with one of these types: " + strings.Join(Types, ", ") + ",\n" +
+			"optionally followed by the scope in parentheses, then a colon and a lower-case summary,\n" +
+			"e.g. `feat(ui): add file selection`. Add a `!` before the colon for breaking changes."
+	}
+}
+
+// Format rewrites the subject (first) line of the message in the style,
+// leaving the body untouched.
+func (s Style) Format(message string) string {
+	return s.format(message, nil)
+}
+
+// MarkBreaking rewrites the subject line of the message to mark it as a
+// breaking change, and adds a footer with the description, unless the message
+// already has one.
+func (s Style) MarkBreaking(message, description string) string {
+	message = s.format(message, func(subject *Subject) {
+		subject.Breaking = true
+		subject.Emoji = breakingEmoji
+	})
+	if strings.Contains(message, "BREAKING CHANGE:") || strings.Contains(message, BreakingChange+":") {
+		return message
+	}
+	return AppendTrailers(message, Trailer{Key: BreakingChange, Value: description})
+}
+
+// WithScope rewrites the subject line of the message with the scope, should it
+// have none, or when override is set, a different one.
+func (s Style) WithScope(message, scope string, override bool) string {
+	return s.format(message, func(subject *Subject) {
+		if override || subject.Scope == "" {
+			subject.Scope = scope
+		}
+	})
+}
+
+// format rewrites the subject line in the style, after any modification.
+func (s Style) format(message string, modify func(*Subject)) string {
+	message = strings.TrimSpace(message)
+	first, rest, hasBody := strings.Cut(message, "\n")
+	if strings.TrimSpace(first) == "" {
+		return message
+	}
+
+	subject := ParseSubject(first)
+	if modify != nil {
+		modify(&subject)
+	}
+	if subject.Emoji == "" {
+		subject.Emoji = emojiForType(subject)
+	}
+	subject.Description = strings.TrimSuffix(strings.TrimSpace(subject.Description), ".")
+
+	// the ticket is kept where it was, except by a custom style, which places
+	// it as its template does
+	description := subject.Description
+	if subject.ticketText != "" && !subject.ticketLeading {
+		description = strings.TrimSpace(subject.ticketText + " " + description)
+	}
+
+	var line string
+	switch s.Name {
+	case Gitmoji:
+		line = strings.TrimSpace(subject.Emoji + " " + capitalize(description))
+	case Plain:
+		line = capitalize(description)
+	case Custom:
+		var sb strings.Builder
+		if err := s.tmpl.Execute(&sb, subject); err != nil {
+			return message
+		}
+		line = strings.TrimSpace(sb.String())
+	default:
+		if subject.Type == "" {
+			// a type is not made up for a subject without one
+			line = description
+			break
+		}
+		line = subject.Type
+		if subject.Scope != "" {
+			line += fmt.Sprintf("(%s)", subject.Scope)
+		}
+		if subject.Breaking {
+			line += "!"
+		}
+		line += ": " + uncapitalize(description)
+	}
+	if subject.ticketLeading && s.Name != Custom {
+		line = subject.ticketText + " " + line
+	}
+
+	if !hasBody {
+		return line
+	}
+	return line + "\n" + rest
+}
+
+func capitalize(text string) string {
+	if text == "" {
+		return text
+	}
+	r, size := utf8.DecodeRuneInString(text)
+	return string(unicode.ToUpper(r)) + text[size:]
+}
+
+// uncapitalize lower-cases the first letter, unless the first word is an
+// acronym or identifier such as API or README.
+func uncapitalize(text string) string {
+	if text == "" {
+		return text
+	}
+	r, size := utf8.DecodeRuneInString(text)
+	next, _ := utf8.DecodeRuneInString(text[size:])
+	if unicode.IsUpper(next) || unicode.IsDigit(next) {
+		return text
+	}
+	return string(unicode.ToLower(r)) + text[size:]
+}
@@ -0,0 +1,140 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSubject(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected Subject
+	}{
+		{"feat: add file selection", Subject{Type: "feat", Description: "add file selection"}},
+		{"fix(ui)!: drop the legacy flag", Subject{Type: "fix", Scope: "ui", Breaking: true, Description: "drop the legacy flag"}},
+		{"✨ Add file selection", Subject{Type: "feat", Emoji: "✨", Description: "Add file selection"}},
+		{":bug: Fix the crash", Subject{Type: "fix", Emoji: "🐛", Description: "Fix the crash"}},
+		{"♻️ Tidy up", Subject{Type: "refactor", Emoji: "♻️", Description: "Tidy up"}},
+		{"[ui] Add file selection", Subject{Scope: "ui", Description: "Add file selection"}},
+		{"PROJ-123: feat: add file selection", Subject{Type: "feat", Ticket: "PROJ-123", Description: "add file selection", ticketText: "PROJ-123:", ticketLeading: true}},
+		{"fix: [PROJ-9] handle resize", Subject{Type: "fix", Ticket: "PROJ-9", Description: "handle resize", ticketText: "[PROJ-9]"}},
+		{"Update README.md", Subject{Description: "Update README.md"}},
+		{"Note: not a type", Subject{Description: "Note: not a type"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.line, func(t *testing.T) {
+			assert.Equal(t, test.expected, ParseSubject(test.line))
+		})
+	}
+}
+
+func TestNewStyle(t *testing.T) {
+	t.Run("Defaults to conventional", func(t *testing.T) {
+		style, err := NewStyle("", "")
+		assert.NoError(t, err)
+		assert.Equal(t, Conventional, style.Name)
+	})
+
+	t.Run("Unknown style", func(t *testing.T) {
+		_, err := NewStyle("fancy", "")
+		assert.ErrorContains(t, err, `unknown style "fancy"`)
+	})
+
+	t.Run("Custom style without a template", func(t *testing.T) {
+		_, err := NewStyle(Custom, "")
+		assert.Error(t, err)
+	})
+
+	t.Run("Custom style with an unknown field", func(t *testing.T) {
+		_, err := NewStyle(Custom, "{{.Nope}}")
+		assert.Error(t, err)
+	})
+
+	t.Run("Custom style instructions", func(t *testing.T) {
+		style, err := NewStyle(Custom, "[{{.Scope}}] {{.Description}}")
+		assert.NoError(t, err)
+		assert.Contains(t, style.Instructions(), "`[<scope>] <summary>`")
+	})
+}
+
+func TestFormat(t *testing.T) {
+	custom, err := NewStyle(Custom, "{{with .Ticket}}{{.}} {{end}}[{{.Scope}}] {{.Description}}")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		style    Style
+		message  string
+		expected string
+	}{
+		{"Conventional unchanged", Style{}, "feat(ui): add file selection", "feat(ui): add file selection"},
+		{"Conventional from gitmoji", Style{Name: Conventional}, "✨ Add file selection.", "feat: add file selection"},
+		{"Conventional keeps acronyms", Style{}, "docs: README tweaks", "docs: README tweaks"},
+		{"Conventional without a type", Style{}, "Update dependencies", "Update dependencies"},
+		{"Conventional keeps a leading ticket", Style{}, "PROJ-123: feat: add file selection", "PROJ-123: feat: add file selection"},
+		{"Conventional keeps a ticket after the type", Style{}, "fix(ui): [PROJ-9] handle resize", "fix(ui): [PROJ-9] handle resize"},
+		{"Conventional keeps a ticket without a type", Style{}, "PROJ-123 Update dependencies", "PROJ-123 Update dependencies"},
+		{"Gitmoji keeps the ticket", Style{Name: Gitmoji}, "PROJ-123: feat: add file selection", "PROJ-123: ✨ Add file selection"},
+		{"Gitmoji without a type", Style{Name: Gitmoji}, "Update dependencies", "Update dependencies"},
+		{"Plain keeps the ticket", Style{Name: Plain}, "fix: PROJ-9 handle resize", "PROJ-9 handle resize"},
+		{"Gitmoji from conventional", Style{Name: Gitmoji}, "fix: handle empty diffs", "🐛 Handle empty diffs"},
+		{"Gitmoji breaking change", Style{Name: Gitmoji}, "feat!: drop the v1 API", "💥 Drop the v1 API"},
+		{"Plain from conventional", Style{Name: Plain}, "refactor(git): extract parser", "Extract parser"},
+		{"Custom from conventional", custom, "fix(ui): handle resize", "[ui] handle resize"},
+		{"Custom with ticket", custom, "PROJ-7 fix(ui): handle resize", "PROJ-7 [ui] handle resize"},
+		{"Keeps the body", Style{Name: Plain}, "feat: add x\n\n- first\n- second", "Add x\n\n- first\n- second"},
+		{"Empty", Style{Name: Gitmoji}, "  \n", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.style.Format(test.message))
+		})
+	}
+}
+
+func TestMarkBreaking(t *testing.T) {
+	tests := []struct {
+		name     string
+		style    Style
+		message  string
+		expected string
+	}{
+		{"Conventional", Style{}, "feat(git): add FileAt", "feat(git)!: add FileAt\n\nBREAKING-CHANGE: Diff was removed"},
+		{"Gitmoji", Style{Name: Gitmoji}, "✨ Add FileAt", "💥 Add FileAt\n\nBREAKING-CHANGE: Diff was removed"},
+		{"Joins trailers", Style{}, "fix: x\n\nBody.\n\nRefs: #1", "fix!: x\n\nBody.\n\nRefs: #1\nBREAKING-CHANGE: Diff was removed"},
+		{"Keeps existing footer", Style{}, "feat!: x\n\nBREAKING CHANGE: the model said so", "feat!: x\n\nBREAKING CHANGE: the model said so"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.style.MarkBreaking(test.message, "Diff was removed"))
+		})
+	}
+}
+
+func TestWithScope(t *testing.T) {
+	custom, err := NewStyle(Custom, "[{{.Scope}}] {{.Description}}")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		style    Style
+		message  string
+		override bool
+		expected string
+	}{
+		{"Inserted", Style{}, "feat: add login\n\nBody.", false, "feat(api): add login\n\nBody."},
+		{"Kept", Style{}, "feat(auth): add login", false, "feat(auth): add login"},
+		{"Corrected", Style{}, "feat(auth)!: add login", true, "feat(api)!: add login"},
+		{"Custom", custom, "fix: handle resize", false, "[api] handle resize"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.style.WithScope(test.message, "api", test.override))
+		})
+	}
+}
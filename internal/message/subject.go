@@ -0,0 +1,160 @@
+package message
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Types are the conventional commit types the model is asked to choose from.
+var Types = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}
+
+// gitmojis maps each conventional commit type to its usual gitmoji.
+var gitmojis = map[string]string{
+	"feat":     "✨",
+	"fix":      "🐛",
+	"docs":     "📝",
+	"style":    "🎨",
+	"refactor": "♻️",
+	"perf":     "⚡️",
+	"test":     "✅",
+	"build":    "📦️",
+	"ci":       "👷",
+	"chore":    "🔧",
+	"revert":   "⏪️",
+}
+
+// shortcodes maps the gitmoji shortcodes (as in `:sparkles:`) to their emoji.
+var shortcodes = map[string]string{
+	"sparkles":            "✨",
+	"bug":                 "🐛",
+	"ambulance":           "🚑️",
+	"memo":                "📝",
+	"art":                 "🎨",
+	"recycle":             "♻️",
+	"zap":                 "⚡️",
+	"white_check_mark":    "✅",
+	"package":             "📦️",
+	"construction_worker": "👷",
+	"wrench":              "🔧",
+	"rewind":              "⏪️",
+	"boom":                "💥",
+	"fire":                "🔥",
+	"lipstick":            "💄",
+	"lock":                "🔒️",
+	"arrow_up":            "⬆️",
+	"arrow_down":          "⬇️",
+}
+
+const breakingEmoji = "💥"
+
+var (
+	ticketPrefix       = regexp.MustCompile(`^\[?([A-Z][A-Z0-9]+-[0-9]+)\]?:?\s+`)
+	shortcodePrefix    = regexp.MustCompile(`^:([a-z0-9_+-]+):\s*`)
+	bracketScopePrefix = regexp.MustCompile(`^\[([^\]]+)\]:?\s*`)
+	conventionalPrefix = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^)]*)\))?(!)?:\s*`)
+)
+
+// Subject holds the parts of a commit message's subject line, and is what a
+// custom style's template is rendered with.
+type Subject struct {
+	Type        string // conventional commit type, e.g. feat
+	Scope       string
+	Breaking    bool
+	Emoji       string // gitmoji, e.g. ✨
+	Ticket      string // issue tracker key, e.g. PROJ-123
+	Description string
+
+	// how the ticket was written, e.g. [PROJ-123], and whether it came before
+	// the type, so that the built-in styles can keep it where it was
+	ticketText    string
+	ticketLeading bool
+}
+
+// ParseSubject picks apart a subject line written in any of the supported
+// styles, so that it can be rewritten in another. Parts that are not present
+// are left empty, and anything unrecognized is kept in the description.
+func ParseSubject(line string) Subject {
+	var subject Subject
+	rest := strings.TrimSpace(line)
+
+	if m := ticketPrefix.FindStringSubmatch(rest); m != nil {
+		subject.Ticket, subject.ticketText, subject.ticketLeading = m[1], strings.TrimSpace(m[0]), true
+		rest = rest[len(m[0]):]
+	}
+
+	if m := shortcodePrefix.FindStringSubmatch(rest); m != nil {
+		if emoji, ok := shortcodes[m[1]]; ok {
+			subject.Emoji = emoji
+			rest = rest[len(m[0]):]
+		}
+	} else if emoji := leadingEmoji(rest); emoji != "" {
+		subject.Emoji = emoji
+		rest = strings.TrimSpace(rest[len(emoji):])
+	}
+
+	if m := bracketScopePrefix.FindStringSubmatch(rest); m != nil {
+		subject.Scope = strings.TrimSpace(m[1])
+		rest = rest[len(m[0]):]
+	}
+
+	if m := conventionalPrefix.FindStringSubmatch(rest); m != nil && slices.Contains(Types, strings.ToLower(m[1])) {
+		subject.Type = strings.ToLower(m[1])
+		if m[2] != "" {
+			subject.Scope = strings.TrimSpace(m[2])
+		}
+		subject.Breaking = m[3] != ""
+		rest = rest[len(m[0]):]
+	}
+
+	if subject.Ticket == "" {
+		if m := ticketPrefix.FindStringSubmatch(rest); m != nil {
+			subject.Ticket, subject.ticketText = m[1], strings.TrimSpace(m[0])
+			rest = rest[len(m[0]):]
+		}
+	}
+
+	if subject.Type == "" {
+		subject.Type = typeForEmoji(subject.Emoji)
+	}
+	if subject.Emoji == breakingEmoji {
+		subject.Breaking = true
+	}
+
+	subject.Description = strings.TrimSpace(rest)
+	return subject
+}
+
+// leadingEmoji returns the emoji (including any variation selectors and
+// joiners) at the start of the text, if there is one.
+func leadingEmoji(text string) string {
+	end := 0
+	for end < len(text) {
+		r, size := utf8.DecodeRuneInString(text[end:])
+		isEmoji := unicode.Is(unicode.So, r) && r > unicode.MaxLatin1
+		isModifier := r == 0xFE0F || r == 0x200D || unicode.Is(unicode.Sk, r) && r > unicode.MaxLatin1
+		if !isEmoji && (end == 0 || !isModifier) {
+			break
+		}
+		end += size
+	}
+	return text[:end]
+}
+
+func typeForEmoji(emoji string) string {
+	for commitType, e := range gitmojis {
+		if strings.TrimSuffix(e, "\uFE0F") == strings.TrimSuffix(emoji, "\uFE0F") {
+			return commitType
+		}
+	}
+	return ""
+}
+
+func emojiForType(subject Subject) string {
+	if subject.Breaking {
+		return breakingEmoji
+	}
+	return gitmojis[subject.Type]
+}
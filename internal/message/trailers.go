@@ -0,0 +1,94 @@
+package message
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	CoAuthoredBy = "Co-authored-by"
+	SignedOffBy  = "Signed-off-by"
+)
+
+var trailerLine = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*):\s*(.*)$`)
+
+// Trailer is a `Key: value` line at the end of a commit message, as handled by
+// `git interpret-trailers`.
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+func (t Trailer) String() string {
+	return t.Key + ": " + t.Value
+}
+
+// ParseTrailer parses a single `Key: value` line.
+func ParseTrailer(line string) (Trailer, bool) {
+	m := trailerLine.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil || strings.TrimSpace(m[2]) == "" {
+		return Trailer{}, false
+	}
+	return Trailer{Key: m[1], Value: strings.TrimSpace(m[2])}, true
+}
+
+// SplitTrailers separates the trailer block from the rest of the message. As
+// with git, the trailer block is the last paragraph, provided it is not also
+// the first (the subject), and every line in it is a trailer or a
+// continuation of one (a line starting with whitespace).
+func SplitTrailers(message string) (string, []Trailer) {
+	message = strings.TrimRight(message, " \t\n")
+	index := strings.LastIndex(message, "\n\n")
+	if index < 0 {
+		return message, nil
+	}
+
+	var trailers []Trailer
+	for _, line := range strings.Split(message[index+2:], "\n") {
+		if line != "" && (line[0] == ' ' || line[0] == '\t') && len(trailers) > 0 {
+			trailers[len(trailers)-1].Value += " " + strings.TrimSpace(line)
+			continue
+		}
+		trailer, ok := ParseTrailer(line)
+		if !ok {
+			return message, nil
+		}
+		trailers = append(trailers, trailer)
+	}
+
+	return strings.TrimRight(message[:index], " \t\n"), trailers
+}
+
+// AppendTrailers adds the trailers to the end of the message, joining any
+// existing trailer block, and skipping those that are already present (as
+// `git interpret-trailers --if-exists addIfDifferent` does).
+func AppendTrailers(message string, trailers ...Trailer) string {
+	message = strings.TrimRight(message, " \t\n")
+	_, existing := SplitTrailers(message)
+
+	var lines []string
+	for _, trailer := range trailers {
+		if !containsTrailer(existing, trailer) {
+			existing = append(existing, trailer)
+			lines = append(lines, trailer.String())
+		}
+	}
+
+	switch {
+	case len(lines) == 0:
+		return message
+	case len(existing) > len(lines):
+		return message + "\n" + strings.Join(lines, "\n")
+	default:
+		return message + "\n\n" + strings.Join(lines, "\n")
+	}
+}
+
+func containsTrailer(trailers []Trailer, trailer Trailer) bool {
+	for _, t := range trailers {
+		if strings.EqualFold(t.Key, trailer.Key) && t.Value == trailer.Value {
+			return true
+		}
+	}
+	return false
+}
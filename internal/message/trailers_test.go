@@ -0,0 +1,57 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitTrailers(t *testing.T) {
+	t.Run("No trailers", func(t *testing.T) {
+		body, trailers := SplitTrailers("feat: add x\n\nSome details.\n")
+		assert.Equal(t, "feat: add x\n\nSome details.", body)
+		assert.Empty(t, trailers)
+	})
+
+	t.Run("Subject only", func(t *testing.T) {
+		body, trailers := SplitTrailers("fix: handle Key: value")
+		assert.Equal(t, "fix: handle Key: value", body)
+		assert.Empty(t, trailers)
+	})
+
+	t.Run("Trailer block with a continuation", func(t *testing.T) {
+		body, trailers := SplitTrailers("feat: add x\n\nDetails.\n\nCo-authored-by: Jo <jo@example.com>\nReviewed-by: A\n  and B\n")
+		assert.Equal(t, "feat: add x\n\nDetails.", body)
+		assert.Equal(t, []Trailer{
+			{Key: "Co-authored-by", Value: "Jo <jo@example.com>"},
+			{Key: "Reviewed-by", Value: "A and B"},
+		}, trailers)
+	})
+
+	t.Run("Last paragraph is not all trailers", func(t *testing.T) {
+		body, trailers := SplitTrailers("feat: add x\n\nCloses: #1\nand more prose")
+		assert.Equal(t, "feat: add x\n\nCloses: #1\nand more prose", body)
+		assert.Empty(t, trailers)
+	})
+}
+
+func TestAppendTrailers(t *testing.T) {
+	coAuthor := Trailer{Key: CoAuthoredBy, Value: "Jo <jo@example.com>"}
+	signOff := Trailer{Key: SignedOffBy, Value: "Me <me@example.com>"}
+
+	t.Run("New trailer block", func(t *testing.T) {
+		assert.Equal(t,
+			"feat: add x\n\nDetails.\n\nCo-authored-by: Jo <jo@example.com>\nSigned-off-by: Me <me@example.com>",
+			AppendTrailers("feat: add x\n\nDetails.\n", coAuthor, signOff))
+	})
+
+	t.Run("Joins an existing trailer block, skipping duplicates", func(t *testing.T) {
+		assert.Equal(t,
+			"feat: add x\n\nSigned-off-by: Me <me@example.com>\nCo-authored-by: Jo <jo@example.com>",
+			AppendTrailers("feat: add x\n\nSigned-off-by: Me <me@example.com>", coAuthor, signOff))
+	})
+
+	t.Run("Nothing to add", func(t *testing.T) {
+		assert.Equal(t, "feat: add x", AppendTrailers("feat: add x\n"))
+	})
+}
@@ -0,0 +1,148 @@
+package message
+
+import (
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/rivo/uniseg"
+)
+
+const tabSize = 4
+
+// Characters that must not start a line (closing brackets and punctuation),
+// or end one (opening brackets), as in the Japanese kinsoku shori rules.
+const (
+	noLineStart = "、。，．,.!?！？：；:;)）]］}｝」』】〉》〕ー々ぁぃぅぇぉっゃゅょゎァィゥェォッャュョヮヵヶ"
+	noLineEnd   = "(（[［{｛「『【〈《〔"
+)
+
+type token struct {
+	text  string
+	width int
+	space bool
+	glue  bool // must stay on the same line as the preceding token
+	wide  bool
+}
+
+// Wrap wraps each line of the text so that it is no wider than the given
+// number of columns, measuring the display width of each character. Lines
+// are broken at spaces, and also between wide (e.g. CJK) characters, as
+// those languages do not separate words with spaces. Words that are longer
+// than the width, such as URLs, are not broken.
+func Wrap(text string, width int) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = wrapLine(line, width)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func wrapLine(line string, width int) string {
+	if runewidth.StringWidth(line) <= width {
+		return line
+	}
+
+	var sb strings.Builder
+	var current strings.Builder
+	currentWidth := 0
+	pending := ""
+
+	for _, tok := range tokenize(line) {
+		if tok.space {
+			pending += tok.text
+			continue
+		}
+
+		pendingWidth := runewidth.StringWidth(pending)
+		if currentWidth > 0 && currentWidth+pendingWidth+tok.width > width && !tok.glue {
+			sb.WriteString(current.String())
+			sb.WriteString("\n")
+			current.Reset()
+			currentWidth = 0
+		} else {
+			current.WriteString(pending)
+			currentWidth += pendingWidth
+		}
+		pending = ""
+
+		current.WriteString(tok.text)
+		currentWidth += tok.width
+	}
+
+	sb.WriteString(current.String())
+	return sb.String()
+}
+
+// tokenize splits the line into the units that may not be broken: runs of
+// spaces, words, and individual wide characters, where any opening brackets
+// are kept with the character that follows them.
+func tokenize(line string) []token {
+	var tokens []token
+	var word strings.Builder
+	wordWidth := 0
+	opening := ""
+
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, token{text: word.String(), width: wordWidth})
+			word.Reset()
+			wordWidth = 0
+		}
+	}
+
+	graphemes := uniseg.NewGraphemes(line)
+	for graphemes.Next() {
+		cluster := graphemes.Str()
+		clusterWidth := runewidth.StringWidth(cluster)
+		if cluster == "\t" {
+			clusterWidth = tabSize
+		}
+
+		switch {
+		case cluster == " " || cluster == "\t":
+			flush()
+			if n := len(tokens); n > 0 && tokens[n-1].space {
+				tokens[n-1].text += cluster
+				tokens[n-1].width += clusterWidth
+			} else {
+				tokens = append(tokens, token{text: cluster, width: clusterWidth, space: true})
+			}
+
+		case strings.Contains(noLineStart, cluster) && (clusterWidth > 1 || word.Len() == 0 && afterWide(tokens)):
+			flush()
+			tokens = append(tokens, token{text: cluster, width: clusterWidth, glue: true, wide: clusterWidth > 1})
+
+		case strings.Contains(noLineEnd, cluster) && clusterWidth > 1:
+			flush()
+			opening += cluster
+
+		case clusterWidth > 1:
+			flush()
+			text := opening + cluster
+			tokens = append(tokens, token{text: text, width: runewidth.StringWidth(text), wide: true})
+			opening = ""
+
+		default:
+			if opening != "" {
+				word.WriteString(opening)
+				wordWidth += runewidth.StringWidth(opening)
+				opening = ""
+			}
+			word.WriteString(cluster)
+			wordWidth += clusterWidth
+		}
+	}
+
+	flush()
+	if opening != "" {
+		tokens = append(tokens, token{text: opening, width: runewidth.StringWidth(opening)})
+	}
+	return tokens
+}
+
+// afterWide reports whether the last token is a wide character, after which
+// narrow punctuation is kept on the same line too.
+func afterWide(tokens []token) bool {
+	n := len(tokens)
+	return n > 0 && tokens[n-1].wide
+}
@@ -0,0 +1,42 @@
+package message
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrap(t *testing.T) {
+	t.Run("Short lines are unchanged", func(t *testing.T) {
+		assert.Equal(t, "feat: add x\n\n  indented", Wrap("feat: add x\n\n  indented", 72))
+	})
+
+	t.Run("Breaks at spaces", func(t *testing.T) {
+		assert.Equal(t, "Die Änderung fügt eine\nSpracheinstellung hinzu.", Wrap("Die Änderung fügt eine Spracheinstellung hinzu.", 24))
+	})
+
+	t.Run("Long words are not broken", func(t *testing.T) {
+		assert.Equal(t, "see\nhttps://example.com/a/very/long/url\nfor more", Wrap("see https://example.com/a/very/long/url for more", 10))
+	})
+
+	t.Run("Breaks between wide characters", func(t *testing.T) {
+		text := "この変更では、コミットメッセージの生成に言語設定を追加し、モデルが指定された言語でメッセージを書くようにします。"
+		wrapped := Wrap(text, 72)
+
+		assert.Equal(t, text, strings.ReplaceAll(wrapped, "\n", ""))
+		for _, line := range strings.Split(wrapped, "\n") {
+			assert.LessOrEqual(t, runewidth.StringWidth(line), 72, line)
+		}
+	})
+
+	t.Run("Punctuation does not start a line", func(t *testing.T) {
+		assert.Equal(t, "日本語、\n日本語", Wrap("日本語、日本語", 8))
+		assert.Equal(t, "日本\n「日本」", Wrap("日本「日本」", 6))
+	})
+
+	t.Run("Mixed scripts", func(t *testing.T) {
+		assert.Equal(t, "Go の\nwrapMessage\nを修正", Wrap("Go の wrapMessage を修正", 12))
+	})
+}
@@ -0,0 +1,64 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/cockroachdb/errors"
+	"github.com/rm-hull/git-commit-summary/internal/interfaces"
+)
+
+// Data is made available to the prompt template when it is rendered.
+type Data struct {
+	Style    string   // instructions for the subject line format
+	Language string   // the language to write in, if not English
+	Format   string   // markdown or plain
+	Breaking []string // breaking changes to the exported API, found by analysis
+	Scope    string   // the scope inferred from the changed paths
+	Diff     string
+	Files    []interfaces.FileChange
+	Commits  []interfaces.Commit
+}
+
+var funcs = template.FuncMap{
+	"fileTable": FileTable,
+}
+
+func Render(text string, data Data) (string, error) {
+	tmpl, err := template.New("prompt").Funcs(funcs).Parse(text)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse prompt template")
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", errors.Wrap(err, "failed to render prompt template")
+	}
+	return sb.String(), nil
+}
+
+// FileTable renders a compact, one-line-per-file summary of the staged
+// changes, so that the model can see additions, deletions, renames and binary
+// files even when they are absent from (or excluded in) the diff.
+func FileTable(files []interfaces.FileChange) string {
+	if len(files) == 0 {
+		return "(none)"
+	}
+
+	var sb strings.Builder
+	for _, file := range files {
+		counts := fmt.Sprintf("+%d -%d", file.Added, file.Removed)
+		if file.Binary {
+			counts = "binary"
+		}
+
+		path := file.Path
+		if file.OldPath != "" {
+			path = fmt.Sprintf("%s -> %s", file.OldPath, file.Path)
+		}
+
+		fmt.Fprintf(&sb, "%-1s  %-12s  %s\n", file.Status, counts, path)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
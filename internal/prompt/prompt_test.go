@@ -0,0 +1,45 @@
+package prompt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rm-hull/git-commit-summary/internal/interfaces"
+)
+
+func TestFileTable(t *testing.T) {
+	t.Run("No files", func(t *testing.T) {
+		assert.Equal(t, "(none)", FileTable(nil))
+	})
+
+	t.Run("Mixed changes", func(t *testing.T) {
+		files := []interfaces.FileChange{
+			{Status: "M", Path: "main.go", Added: 10, Removed: 2},
+			{Status: "D", Path: "logo.png", Binary: true},
+			{Status: "R", OldPath: "old.go", Path: "new.go", Added: 1, Removed: 1},
+		}
+		expected := "" +
+			"M  +10 -2        main.go\n" +
+			"D  binary        logo.png\n" +
+			"R  +1 -1         old.go -> new.go"
+		assert.Equal(t, expected, FileTable(files))
+	})
+}
+
+func TestRender(t *testing.T) {
+	t.Run("Diff and files", func(t *testing.T) {
+		data := Data{
+			Diff:  "diff --git a/main.go b/main.go",
+			Files: []interfaces.FileChange{{Status: "A", Path: "main.go", Added: 1}},
+		}
+		out, err := Render("{{fileTable .Files}}\n---\n{{.Diff}}", data)
+		assert.NoError(t, err)
+		assert.Equal(t, "A  +1 -0         main.go\n---\ndiff --git a/main.go b/main.go", out)
+	})
+
+	t.Run("Invalid template", func(t *testing.T) {
+		_, err := Render("{{.Missing", Data{})
+		assert.Error(t, err)
+	})
+}
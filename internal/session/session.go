@@ -0,0 +1,74 @@
+package session
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Store keeps the last edited commit message for each repository, so that it
+// can be offered again should the commit fail or be aborted, much like git's
+// own COMMIT_EDITMSG.
+type Store struct {
+	dir string
+}
+
+// Session is a kept commit message, along with a hash of the diff it was
+// written for, so that it is only offered again for the same changes.
+type Session struct {
+	Message  string `json:"message"`
+	DiffHash string `json:"diffHash"`
+}
+
+func New(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// HashDiff is the hash of a diff, to tell whether the changes are the same as
+// they were when a message was kept.
+func HashDiff(diff string) string {
+	hash := sha256.Sum256([]byte(diff))
+	return hex.EncodeToString(hash[:])
+}
+
+func (s *Store) Load(gitDir string) (Session, bool) {
+	data, err := os.ReadFile(s.path(gitDir))
+	if err != nil {
+		return Session{}, false
+	}
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil || session.Message == "" {
+		return Session{}, false
+	}
+	return session, true
+}
+
+func (s *Store) Save(gitDir string, session Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode commit message")
+	}
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return errors.Wrap(err, "failed to create session directory")
+	}
+	if err := os.WriteFile(s.path(gitDir), data, 0o600); err != nil {
+		return errors.Wrap(err, "failed to save commit message")
+	}
+	return nil
+}
+
+func (s *Store) Delete(gitDir string) error {
+	if err := os.Remove(s.path(gitDir)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.Wrap(err, "failed to delete saved commit message")
+	}
+	return nil
+}
+
+func (s *Store) path(gitDir string) string {
+	hash := sha256.Sum256([]byte(filepath.Clean(gitDir)))
+	return filepath.Join(s.dir, hex.EncodeToString(hash[:16])+".json")
+}
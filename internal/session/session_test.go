@@ -0,0 +1,56 @@
+package session
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore(t *testing.T) {
+	t.Run("Nothing saved", func(t *testing.T) {
+		s := New(t.TempDir())
+		_, ok := s.Load("/work/repo/.git")
+		assert.False(t, ok)
+	})
+
+	t.Run("Save, load and delete", func(t *testing.T) {
+		s := New(t.TempDir())
+		saved := Session{Message: "feat: unfinished", DiffHash: HashDiff("diff")}
+
+		assert.NoError(t, s.Save("/work/repo/.git", saved))
+		session, ok := s.Load("/work/repo/.git")
+		assert.True(t, ok)
+		assert.Equal(t, saved, session)
+
+		assert.NoError(t, s.Delete("/work/repo/.git"))
+		_, ok = s.Load("/work/repo/.git")
+		assert.False(t, ok)
+
+		assert.NoError(t, s.Delete("/work/repo/.git"), "deleting twice is not an error")
+	})
+
+	t.Run("Keyed by repository", func(t *testing.T) {
+		s := New(t.TempDir())
+
+		assert.NoError(t, s.Save("/work/one/.git", Session{Message: "feat: one"}))
+		assert.NoError(t, s.Save("/work/two/.git", Session{Message: "feat: two"}))
+
+		session, _ := s.Load("/work/one/.git")
+		assert.Equal(t, "feat: one", session.Message)
+		session, _ = s.Load("/work/two/.git/")
+		assert.Equal(t, "feat: two", session.Message)
+	})
+
+	t.Run("Unreadable", func(t *testing.T) {
+		s := New(t.TempDir())
+		assert.NoError(t, os.WriteFile(s.path("/work/repo/.git"), []byte("feat: not json"), 0o600))
+		_, ok := s.Load("/work/repo/.git")
+		assert.False(t, ok)
+	})
+}
+
+func TestHashDiff(t *testing.T) {
+	assert.Equal(t, HashDiff("diff"), HashDiff("diff"))
+	assert.NotEqual(t, HashDiff("diff"), HashDiff("diff\n+more"))
+}
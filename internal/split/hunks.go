@@ -0,0 +1,136 @@
+package split
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rm-hull/git-commit-summary/internal/interfaces"
+)
+
+// hunkSeparator joins a path and the number of one of its hunks, as in
+// `main.go#2`, so that a file's hunks may be split between commits.
+const hunkSeparator = "#"
+
+// FilePatch is a file's part of a patch, split into its hunks.
+type FilePatch struct {
+	Header string
+	Hunks  []string
+}
+
+// ParsePatch splits a file's patch into the header and its hunks. A binary
+// patch, or one that only renames the file, is all header.
+func ParsePatch(patch string) FilePatch {
+	var p FilePatch
+	for _, line := range strings.SplitAfter(patch, "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@ "):
+			p.Hunks = append(p.Hunks, line)
+		case len(p.Hunks) > 0:
+			p.Hunks[len(p.Hunks)-1] += line
+		default:
+			p.Header += line
+		}
+	}
+	return p
+}
+
+// Select is the patch with only the given hunks, numbered from 1, or the whole
+// patch when none are given. Each hunk keeps the line numbers of the original
+// patch: git apply finds where it goes when earlier hunks are left out.
+func (p FilePatch) Select(hunks []int) string {
+	if len(hunks) == 0 {
+		return p.Header + strings.Join(p.Hunks, "")
+	}
+	var sb strings.Builder
+	sb.WriteString(p.Header)
+	for _, n := range hunks {
+		if n >= 1 && n <= len(p.Hunks) {
+			sb.WriteString(p.Hunks[n-1])
+		}
+	}
+	return sb.String()
+}
+
+// Splittable reports whether a staged file's hunks may go in different
+// commits: only a modified text file's can, as any other is all or nothing.
+func Splittable(change interfaces.FileChange) bool {
+	return change.Status == "M" && !change.Binary
+}
+
+// DiffPath is the new path of a file, from the `diff --git a/old b/new` line
+// that starts its part of a diff. When the file was not renamed, both paths
+// are the same, which tells where they part even should the path itself
+// contain " b/".
+func DiffPath(header string) string {
+	paths := strings.TrimPrefix(header, "diff --git ")
+	if half := (len(paths) - 1) / 2; len(paths)%2 == 1 && paths[half] == ' ' &&
+		strings.HasPrefix(paths, "a/") && paths[half+1:half+3] == "b/" && paths[2:half] == paths[half+3:] {
+		return paths[half+3:]
+	}
+	return paths[strings.LastIndex(paths, " b/")+len(" b/"):]
+}
+
+// CountHunks counts the hunks of each file in the diff, by its new path.
+func CountHunks(diff string) map[string]int {
+	counts := map[string]int{}
+	path := ""
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			path = DiffPath(line)
+		case strings.HasPrefix(line, "@@ ") && path != "":
+			counts[path]++
+		}
+	}
+	return counts
+}
+
+// LabelHunks names each hunk of the files whose hunks may be split between
+// commits, at the end of its header, so that the model may refer to it.
+func LabelHunks(diff string, changes []interfaces.FileChange) string {
+	splittable := splittableHunks(CountHunks(diff), changes)
+
+	lines := strings.Split(diff, "\n")
+	path, n := "", 0
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			path, n = DiffPath(line), 0
+		case strings.HasPrefix(line, "@@ ") && splittable[path] > 0:
+			n++
+			lines[i] = fmt.Sprintf("%s [%s]", line, hunkRef(path, n))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// splittableHunks counts the hunks of the files with more than one, that may
+// be split between commits.
+func splittableHunks(counts map[string]int, changes []interfaces.FileChange) map[string]int {
+	hunks := map[string]int{}
+	for _, change := range changes {
+		if Splittable(change) && counts[change.Path] > 1 {
+			hunks[change.Path] = counts[change.Path]
+		}
+	}
+	return hunks
+}
+
+func hunkRef(path string, n int) string {
+	return path + hunkSeparator + strconv.Itoa(n)
+}
+
+// parseHunkRef splits a reference to a hunk into the path and its number,
+// reporting false for a reference to a whole file.
+func parseHunkRef(ref string) (string, int, bool) {
+	i := strings.LastIndex(ref, hunkSeparator)
+	if i < 0 {
+		return ref, 0, false
+	}
+	n, err := strconv.Atoi(ref[i+len(hunkSeparator):])
+	if err != nil || n < 1 {
+		return ref, 0, false
+	}
+	return ref[:i], n, true
+}
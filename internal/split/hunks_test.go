@@ -0,0 +1,47 @@
+package split
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rm-hull/git-commit-summary/internal/interfaces"
+)
+
+const (
+	mainHeader = "diff --git a/main.go b/main.go\nindex 1111111..2222222 100644\n--- a/main.go\n+++ b/main.go\n"
+	mainHunk1  = "@@ -1,3 +1,3 @@ package main\n import \"fmt\"\n-// old\n+// new\n \n"
+	mainHunk2  = "@@ -20,3 +20,4 @@ func main() {\n \tfmt.Println()\n+\tfmt.Println()\n }\n\\ No newline at end of file\n"
+	mainPatch  = mainHeader + mainHunk1 + mainHunk2
+)
+
+func TestParsePatch(t *testing.T) {
+	p := ParsePatch(mainPatch)
+	assert.Equal(t, mainHeader, p.Header)
+	assert.Equal(t, []string{mainHunk1, mainHunk2}, p.Hunks)
+
+	assert.Equal(t, mainPatch, p.Select(nil))
+	assert.Equal(t, mainHeader+mainHunk2, p.Select([]int{2}))
+	assert.Equal(t, mainHeader+mainHunk1, p.Select([]int{1, 7}))
+
+	binary := "diff --git a/logo.png b/logo.png\nGIT binary patch\nliteral 1\nIcmZ?l0000\n\n"
+	assert.Equal(t, FilePatch{Header: binary}, ParsePatch(binary))
+}
+
+func TestLabelHunks(t *testing.T) {
+	diff := mainPatch + "diff --git a/README.md b/README.md\n--- a/README.md\n+++ b/README.md\n@@ -1 +1 @@\n-a\n+b\n"
+	changes := []interfaces.FileChange{{Status: "M", Path: "main.go"}, {Status: "M", Path: "README.md"}}
+
+	assert.Equal(t, map[string]int{"main.go": 2, "README.md": 1}, CountHunks(diff))
+
+	labeled := LabelHunks(diff, changes)
+	assert.Contains(t, labeled, "@@ -1,3 +1,3 @@ package main [main.go#1]\n")
+	assert.Contains(t, labeled, "@@ -20,3 +20,4 @@ func main() { [main.go#2]\n")
+	assert.Contains(t, labeled, "@@ -1 +1 @@\n", "a single hunk is not labeled")
+}
+
+func TestDiffPath(t *testing.T) {
+	assert.Equal(t, "main.go", DiffPath("diff --git a/main.go b/main.go"))
+	assert.Equal(t, "new.go", DiffPath("diff --git a/old.go b/new.go"))
+	assert.Equal(t, "docs a b/c.md", DiffPath("diff --git a/docs a b/c.md b/docs a b/c.md"))
+}
@@ -0,0 +1,179 @@
+package split
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/rm-hull/git-commit-summary/internal/interfaces"
+)
+
+// Commit is one of the logical commits that a staged change is split into.
+// Its files are paths, for the whole of a file's changes, or references to
+// hunks, such as `main.go#2`, for those of a file split between commits.
+type Commit struct {
+	Message string   `json:"message"`
+	Files   []string `json:"files"`
+}
+
+// Paths are the paths of the files the commit changes, in whole or in part.
+func (c Commit) Paths() []string {
+	var paths []string
+	for _, file := range c.Files {
+		if path, _, _ := parseHunkRef(file); !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// Patch is the commit's part of the staged patch, given each file's patch by
+// its path.
+func (c Commit) Patch(patches map[string]FilePatch) string {
+	var paths []string
+	hunks := map[string][]int{}
+	for _, file := range c.Files {
+		path, n := file, 0
+		if _, ok := patches[file]; !ok {
+			path, n, _ = parseHunkRef(file)
+		}
+		if !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+		if n > 0 {
+			hunks[path] = append(hunks[path], n)
+		}
+	}
+
+	var sb strings.Builder
+	for _, path := range paths {
+		sb.WriteString(patches[path].Select(hunks[path]))
+	}
+	return sb.String()
+}
+
+type plan struct {
+	Commits []Commit `json:"commits"`
+}
+
+// ParsePlan extracts the proposed commits from the model's response, given
+// the number of hunks of each file in the diff. Files or hunks that the model
+// does not know about, or that it assigned more than once, are dropped; any
+// staged files or hunks it forgot about are added to the last commit, so that
+// nothing staged is lost. A file whose hunks all end up in one commit is
+// referred to by its path.
+func ParsePlan(response string, changes []interfaces.FileChange, hunks map[string]int) ([]Commit, error) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start == -1 || end < start {
+		return nil, errors.New("model did not respond with a JSON split plan")
+	}
+
+	var p plan
+	if err := json.Unmarshal([]byte(response[start:end+1]), &p); err != nil {
+		return nil, errors.Wrap(err, "failed to parse split plan")
+	}
+
+	// the hunks of each file yet to be assigned, where a file that cannot be
+	// split has just the one
+	splittable := splittableHunks(hunks, changes)
+	unassigned := make(map[string][]int, len(changes))
+	for _, change := range changes {
+		unassigned[change.Path] = []int{0}
+		if n := splittable[change.Path]; n > 0 {
+			unassigned[change.Path] = make([]int, n)
+			for i := range n {
+				unassigned[change.Path][i] = i + 1
+			}
+		}
+	}
+	take := func(path string, n int) []int {
+		remaining := unassigned[path]
+		if n == 0 {
+			delete(unassigned, path)
+			return remaining
+		}
+		i := slices.Index(remaining, n)
+		if i < 0 {
+			return nil
+		}
+		unassigned[path] = slices.Delete(remaining, i, i+1)
+		return []int{n}
+	}
+
+	var commits []assignment
+	for _, commit := range p.Commits {
+		a := assignment{message: strings.TrimSpace(commit.Message), hunks: map[string][]int{}}
+		for _, file := range commit.Files {
+			path, n := file, 0
+			if _, ok := unassigned[file]; !ok {
+				path, n, _ = parseHunkRef(file)
+			}
+			a.add(path, take(path, n))
+		}
+		if len(a.paths) > 0 && a.message != "" {
+			commits = append(commits, a)
+		}
+	}
+
+	if len(commits) == 0 {
+		return nil, errors.New("split plan did not contain any usable commits")
+	}
+
+	last := &commits[len(commits)-1]
+	for _, change := range changes {
+		last.add(change.Path, take(change.Path, 0))
+	}
+
+	result := make([]Commit, len(commits))
+	for i, a := range commits {
+		result[i] = a.commit(splittable)
+	}
+	return result, nil
+}
+
+// assignment is the files and hunks assigned to a commit so far.
+type assignment struct {
+	message string
+	paths   []string
+	hunks   map[string][]int // 0 for a file that cannot be split
+}
+
+func (a *assignment) add(path string, hunks []int) {
+	if len(hunks) == 0 {
+		return
+	}
+	if !slices.Contains(a.paths, path) {
+		a.paths = append(a.paths, path)
+	}
+	a.hunks[path] = append(a.hunks[path], hunks...)
+}
+
+func (a *assignment) commit(splittable map[string]int) Commit {
+	commit := Commit{Message: a.message}
+	for _, path := range a.paths {
+		hunks := a.hunks[path]
+		if len(hunks) == splittable[path] || slices.Contains(hunks, 0) {
+			commit.Files = append(commit.Files, path)
+			continue
+		}
+		slices.Sort(hunks)
+		for _, n := range hunks {
+			commit.Files = append(commit.Files, hunkRef(path, n))
+		}
+	}
+	return commit
+}
+
+// PatchPaths returns the paths needed to produce a patch for the given files,
+// which includes the original path of any renamed or copied file.
+func PatchPaths(files []string, changes []interfaces.FileChange) []string {
+	paths := slices.Clone(files)
+	for _, change := range changes {
+		if change.OldPath != "" && slices.Contains(files, change.Path) && !slices.Contains(paths, change.OldPath) {
+			paths = append(paths, change.OldPath)
+		}
+	}
+	return paths
+}
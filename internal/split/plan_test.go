@@ -0,0 +1,107 @@
+package split
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rm-hull/git-commit-summary/internal/interfaces"
+)
+
+var changes = []interfaces.FileChange{
+	{Status: "M", Path: "main.go"},
+	{Status: "A", Path: "internal/split/plan.go"},
+	{Status: "R", OldPath: "docs/old.md", Path: "docs/new.md"},
+	{Status: "M", Path: "README.md"},
+}
+
+func TestParsePlan(t *testing.T) {
+	t.Run("Fenced JSON", func(t *testing.T) {
+		response := "```json\n" + `{"commits": [
+			{"message": "feat: add split plan", "files": ["internal/split/plan.go", "main.go"]},
+			{"message": "docs: rename docs", "files": ["docs/new.md", "README.md"]}
+		]}` + "\n```"
+
+		commits, err := ParsePlan(response, changes, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []Commit{
+			{Message: "feat: add split plan", Files: []string{"internal/split/plan.go", "main.go"}},
+			{Message: "docs: rename docs", Files: []string{"docs/new.md", "README.md"}},
+		}, commits)
+	})
+
+	t.Run("Unknown, duplicate and forgotten files", func(t *testing.T) {
+		response := `{"commits": [
+			{"message": "feat: add split plan", "files": ["internal/split/plan.go", "made/up.go"]},
+			{"message": "fix: nothing left", "files": ["internal/split/plan.go"]},
+			{"message": "docs: update readme", "files": ["README.md"]}
+		]}`
+
+		commits, err := ParsePlan(response, changes, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []Commit{
+			{Message: "feat: add split plan", Files: []string{"internal/split/plan.go"}},
+			{Message: "docs: update readme", Files: []string{"README.md", "main.go", "docs/new.md"}},
+		}, commits)
+	})
+
+	t.Run("Hunks", func(t *testing.T) {
+		response := `{"commits": [
+			{"message": "feat: add split plan", "files": ["internal/split/plan.go", "main.go#2", "main.go#9"]},
+			{"message": "fix: flags", "files": ["main.go#1", "main.go#2"]},
+			{"message": "docs: rename docs", "files": ["docs/new.md#1", "README.md#1", "README.md#2"]}
+		]}`
+
+		commits, err := ParsePlan(response, changes, map[string]int{"main.go": 3, "docs/new.md": 2, "README.md": 2})
+		assert.NoError(t, err)
+		assert.Equal(t, []Commit{
+			{Message: "feat: add split plan", Files: []string{"internal/split/plan.go", "main.go#2"}},
+			{Message: "fix: flags", Files: []string{"main.go#1"}},
+			{Message: "docs: rename docs", Files: []string{"README.md", "main.go#3", "docs/new.md"}},
+		}, commits)
+		assert.Equal(t, []string{"README.md", "main.go", "docs/new.md"}, commits[2].Paths())
+	})
+
+	t.Run("Whole file after some of its hunks", func(t *testing.T) {
+		response := `{"commits": [
+			{"message": "fix: flags", "files": ["main.go#2"]},
+			{"message": "feat: the rest", "files": ["main.go", "README.md", "internal/split/plan.go", "docs/new.md"]}
+		]}`
+
+		commits, err := ParsePlan(response, changes, map[string]int{"main.go": 3})
+		assert.NoError(t, err)
+		assert.Equal(t, []Commit{
+			{Message: "fix: flags", Files: []string{"main.go#2"}},
+			{Message: "feat: the rest", Files: []string{"main.go#1", "main.go#3", "README.md", "internal/split/plan.go", "docs/new.md"}},
+		}, commits)
+	})
+
+	t.Run("Not JSON", func(t *testing.T) {
+		_, err := ParsePlan("I cannot split this change", changes, nil)
+		assert.EqualError(t, err, "model did not respond with a JSON split plan")
+	})
+
+	t.Run("No usable commits", func(t *testing.T) {
+		_, err := ParsePlan(`{"commits": [{"message": "", "files": ["main.go"]}]}`, changes, nil)
+		assert.EqualError(t, err, "split plan did not contain any usable commits")
+	})
+}
+
+func TestPatchPaths(t *testing.T) {
+	assert.Equal(t,
+		[]string{"docs/new.md", "main.go", "docs/old.md"},
+		PatchPaths([]string{"docs/new.md", "main.go"}, changes))
+}
+
+func TestCommitPatch(t *testing.T) {
+	patches := map[string]FilePatch{
+		"main.go":   ParsePatch(mainPatch),
+		"README.md": ParsePatch("diff --git a/README.md b/README.md\n@@ -1 +1 @@\n-a\n+b\n"),
+	}
+
+	commit := Commit{Files: []string{"main.go#2", "README.md"}}
+	assert.Equal(t, mainHeader+mainHunk2+"diff --git a/README.md b/README.md\n@@ -1 +1 @@\n-a\n+b\n", commit.Patch(patches))
+
+	commit = Commit{Files: []string{"main.go"}}
+	assert.Equal(t, mainPatch, commit.Patch(patches))
+}
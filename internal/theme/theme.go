@@ -0,0 +1,212 @@
+package theme
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/cockroachdb/errors"
+)
+
+// Auto picks the dark or light theme to suit the terminal's background.
+const Auto = "auto"
+
+// Theme is the palette the UI is drawn with, along with the styles used for
+// the rendered Markdown preview and the highlighted diff. The colors are
+// named for their hues in the dark theme, and other themes keep to similar
+// hues, so that a heading is magenta-ish whichever theme is used.
+type Theme struct {
+	Name       string
+	Magenta    lipgloss.TerminalColor // headings and prompts
+	Blue       lipgloss.TerminalColor // progress
+	Cyan       lipgloss.TerminalColor // borders, lists and trailers
+	Grey       lipgloss.TerminalColor // whatever no longer applies
+	Red        lipgloss.TerminalColor // errors and removed words
+	Yellow     lipgloss.TerminalColor // keys in the help
+	Green      lipgloss.TerminalColor // added words
+	Background lipgloss.TerminalColor
+	Glamour    string // the glamour style of the preview
+	Chroma     string // the chroma style of the diff, or blank for none
+}
+
+var presets = map[string]Theme{
+	"dark": {
+		Magenta:    lipgloss.Color("5"),
+		Blue:       lipgloss.Color("12"),
+		Cyan:       lipgloss.Color("6"),
+		Grey:       lipgloss.Color("8"),
+		Red:        lipgloss.Color("9"),
+		Yellow:     lipgloss.Color("#FFFF00"),
+		Green:      lipgloss.Color("10"),
+		Background: lipgloss.Color("#222222"),
+		Glamour:    "dark",
+		Chroma:     "monokai",
+	},
+	"light": {
+		Magenta:    lipgloss.Color("#A626A4"),
+		Blue:       lipgloss.Color("#0451A5"),
+		Cyan:       lipgloss.Color("#00838F"),
+		Grey:       lipgloss.Color("#6E6E6E"),
+		Red:        lipgloss.Color("#C4261D"),
+		Yellow:     lipgloss.Color("#8A6D00"),
+		Green:      lipgloss.Color("#2E7D32"),
+		Background: lipgloss.Color("#DDDDDD"),
+		Glamour:    "light",
+		Chroma:     "github",
+	},
+	"solarized-dark": {
+		Magenta:    lipgloss.Color("#D33682"),
+		Blue:       lipgloss.Color("#268BD2"),
+		Cyan:       lipgloss.Color("#2AA198"),
+		Grey:       lipgloss.Color("#586E75"),
+		Red:        lipgloss.Color("#DC322F"),
+		Yellow:     lipgloss.Color("#B58900"),
+		Green:      lipgloss.Color("#859900"),
+		Background: lipgloss.Color("#073642"),
+		Glamour:    "dark",
+		Chroma:     "solarized-dark",
+	},
+	"solarized-light": {
+		Magenta:    lipgloss.Color("#D33682"),
+		Blue:       lipgloss.Color("#268BD2"),
+		Cyan:       lipgloss.Color("#2AA198"),
+		Grey:       lipgloss.Color("#93A1A1"),
+		Red:        lipgloss.Color("#DC322F"),
+		Yellow:     lipgloss.Color("#B58900"),
+		Green:      lipgloss.Color("#859900"),
+		Background: lipgloss.Color("#EEE8D5"),
+		Glamour:    "light",
+		Chroma:     "solarized-light",
+	},
+	"dracula": {
+		Magenta:    lipgloss.Color("#FF79C6"),
+		Blue:       lipgloss.Color("#BD93F9"),
+		Cyan:       lipgloss.Color("#8BE9FD"),
+		Grey:       lipgloss.Color("#6272A4"),
+		Red:        lipgloss.Color("#FF5555"),
+		Yellow:     lipgloss.Color("#F1FA8C"),
+		Green:      lipgloss.Color("#50FA7B"),
+		Background: lipgloss.Color("#44475A"),
+		Glamour:    "dracula",
+		Chroma:     "dracula",
+	},
+}
+
+// Names are the themes that can be chosen, auto first.
+func Names() []string {
+	names := make([]string, 0, len(presets)+1)
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{Auto}, names...)
+}
+
+// Preset is the named theme.
+func Preset(name string) (Theme, bool) {
+	t, ok := presets[name]
+	t.Name = name
+	return t, ok
+}
+
+// None is the theme without any colors, for NO_COLOR.
+func None() Theme {
+	none := lipgloss.NoColor{}
+	return Theme{
+		Name:       "none",
+		Magenta:    none,
+		Blue:       none,
+		Cyan:       none,
+		Grey:       none,
+		Red:        none,
+		Yellow:     none,
+		Green:      none,
+		Background: none,
+		Glamour:    "notty",
+	}
+}
+
+var hexColor = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Config is the configured choice of theme.
+type Config struct {
+	Name    string            // a preset, or auto
+	Colors  map[string]string // overriding the preset's, by their names
+	NoColor bool
+}
+
+// Parse checks the name of the theme, and parses the colors overriding its
+// own, written as `name=color`, where a color is an ANSI color number or a hex
+// code, e.g. `magenta=#FF00FF` or `yellow=3`.
+func Parse(name string, colors ...string) (Config, error) {
+	if name == "" {
+		name = Auto
+	}
+	if _, ok := presets[name]; !ok && name != Auto {
+		return Config{}, errors.Newf("unknown theme %q, expected one of: %s", name, strings.Join(Names(), ", "))
+	}
+
+	cfg := Config{Name: name, Colors: map[string]string{}}
+	for _, entry := range colors {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || key == "" || value == "" {
+			return Config{}, errors.Newf("expected name=color, got %q", entry)
+		}
+		if _, ok := (&Theme{}).color(key); !ok {
+			return Config{}, errors.Newf("unknown color %q", key)
+		}
+		if n, err := strconv.Atoi(value); (err != nil || n < 0 || n > 255) && !hexColor.MatchString(value) {
+			return Config{}, errors.Newf("invalid color %q for %s, expected 0-255 or a hex code", value, key)
+		}
+		cfg.Colors[key] = value
+	}
+	return cfg, nil
+}
+
+// Resolve picks the theme, asking whether the terminal has a dark background
+// only when the theme is auto.
+func (c Config) Resolve(hasDarkBackground func() bool) Theme {
+	if c.NoColor {
+		return None()
+	}
+
+	name := c.Name
+	if name == Auto || name == "" {
+		name = "light"
+		if hasDarkBackground() {
+			name = "dark"
+		}
+	}
+
+	t, _ := Preset(name)
+	for key, value := range c.Colors {
+		if color, ok := t.color(key); ok {
+			*color = lipgloss.Color(value)
+		}
+	}
+	return t
+}
+
+func (t *Theme) color(name string) (*lipgloss.TerminalColor, bool) {
+	switch name {
+	case "magenta":
+		return &t.Magenta, true
+	case "blue":
+		return &t.Blue, true
+	case "cyan":
+		return &t.Cyan, true
+	case "grey", "gray":
+		return &t.Grey, true
+	case "red":
+		return &t.Red, true
+	case "yellow":
+		return &t.Yellow, true
+	case "green":
+		return &t.Green, true
+	case "background":
+		return &t.Background, true
+	}
+	return nil, false
+}
@@ -0,0 +1,77 @@
+package theme
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+)
+
+func dark() bool  { return true }
+func light() bool { return false }
+
+func TestParse(t *testing.T) {
+	t.Run("Default", func(t *testing.T) {
+		cfg, err := Parse("")
+		assert.NoError(t, err)
+		assert.Equal(t, Auto, cfg.Name)
+		assert.Empty(t, cfg.Colors)
+	})
+
+	t.Run("Preset and colors", func(t *testing.T) {
+		cfg, err := Parse("solarized-light", "magenta=#FF00FF", "yellow=3", "gray=#888")
+		assert.NoError(t, err)
+		assert.Equal(t, "solarized-light", cfg.Name)
+		assert.Equal(t, map[string]string{"magenta": "#FF00FF", "yellow": "3", "gray": "#888"}, cfg.Colors)
+	})
+
+	for name, args := range map[string][]string{
+		"Unknown theme":   {"neon"},
+		"Malformed color": {"dark", "magenta"},
+		"Unknown color":   {"dark", "purple=5"},
+		"Invalid number":  {"dark", "red=256"},
+		"Invalid hex":     {"dark", "red=#GGGGGG"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(args[0], args[1:]...)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	t.Run("Auto", func(t *testing.T) {
+		cfg, _ := Parse(Auto)
+		assert.Equal(t, "dark", cfg.Resolve(dark).Name)
+		assert.Equal(t, "light", cfg.Resolve(light).Name)
+		assert.Equal(t, "light", cfg.Resolve(light).Glamour)
+	})
+
+	t.Run("Preset is not detected", func(t *testing.T) {
+		cfg, _ := Parse("dracula")
+		theme := cfg.Resolve(func() bool { panic("should not be asked") })
+		assert.Equal(t, "dracula", theme.Name)
+		assert.Equal(t, "dracula", theme.Chroma)
+	})
+
+	t.Run("Custom colors", func(t *testing.T) {
+		cfg, _ := Parse("light", "magenta=#FF00FF", "grey=240")
+		theme := cfg.Resolve(light)
+		assert.Equal(t, lipgloss.Color("#FF00FF"), theme.Magenta)
+		assert.Equal(t, lipgloss.Color("240"), theme.Grey)
+		assert.Equal(t, presets["light"].Cyan, theme.Cyan)
+	})
+
+	t.Run("No color", func(t *testing.T) {
+		cfg, _ := Parse("dark", "magenta=#FF00FF")
+		cfg.NoColor = true
+		theme := cfg.Resolve(dark)
+		assert.Equal(t, None(), theme)
+		assert.Equal(t, lipgloss.NoColor{}, theme.Magenta)
+		assert.Empty(t, theme.Chroma)
+	})
+}
+
+func TestNames(t *testing.T) {
+	assert.Equal(t, []string{"auto", "dark", "dracula", "light", "solarized-dark", "solarized-light"}, Names())
+}
@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type choice struct {
+	key   string
+	label string
+}
+
+type choiceMsg string
+
+// choiceViewModel asks the user to pick one of a few options, each selected
+// with a single key press.
+type choiceViewModel struct {
+	message string
+	choices []choice
+}
+
+func initialChoiceViewModel(message string, choices ...choice) *choiceViewModel {
+	return &choiceViewModel{
+		message: message,
+		choices: choices,
+	}
+}
+
+func (m *choiceViewModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *choiceViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		key := strings.ToLower(msg.String())
+		for _, c := range m.choices {
+			if c.key == key {
+				return m, func() tea.Msg { return choiceMsg(c.key) }
+			}
+		}
+		if msg.Type == tea.KeyCtrlC {
+			return m, func() tea.Msg { return abortMsg{} }
+		}
+
+	case errMsg:
+		return m, tea.Quit
+	}
+
+	return m, nil
+}
+
+func (m *choiceViewModel) View() string {
+	options := make([]string, len(m.choices))
+	for i, c := range m.choices {
+		options[i] = fmt.Sprintf("%s:%s", BoldYellow.Render(strings.ToUpper(c.key)), c.label)
+	}
+	return fmt.Sprintf("%s\n%s\n", m.message, strings.Join(options, " "))
+}
@@ -1,14 +1,48 @@
 package ui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/rm-hull/git-commit-summary/internal/theme"
+)
 
 var (
-	Magenta       = lipgloss.NewStyle().Foreground(lipgloss.Color("5"))
-	Blue          = lipgloss.NewStyle().Foreground(lipgloss.Color("12"))
-	Cyan          = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
-	BoldBlue      = Blue.Bold(true).Underline(true)
-	BoldRed       = lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true)
-	BoldYellow    = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#FFD700", Dark: "#FFFF00"}).Bold(true)
-	Background    = lipgloss.NewStyle().Background(lipgloss.AdaptiveColor{Light: "#DDDDDD", Dark: "#222222"}).Bold(true)
-	Strikethrough = lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Strikethrough(true)
+	Magenta       lipgloss.Style
+	Blue          lipgloss.Style
+	Cyan          lipgloss.Style
+	Grey          lipgloss.Style
+	BoldBlue      lipgloss.Style
+	BoldRed       lipgloss.Style
+	BoldYellow    lipgloss.Style
+	Background    lipgloss.Style
+	Strikethrough lipgloss.Style
+	Inserted      lipgloss.Style
+	Deleted       lipgloss.Style
+
+	// activeTheme is the theme the styles above were made from
+	activeTheme theme.Theme
 )
+
+func init() {
+	dark, _ := theme.Preset("dark")
+	ApplyTheme(dark)
+}
+
+// ApplyTheme redraws the styles with the theme's colors, and chooses the
+// styles of the preview and the diff pane. It should be applied before the
+// UI is started.
+func ApplyTheme(t theme.Theme) {
+	activeTheme = t
+
+	Magenta = lipgloss.NewStyle().Foreground(t.Magenta)
+	Blue = lipgloss.NewStyle().Foreground(t.Blue)
+	Cyan = lipgloss.NewStyle().Foreground(t.Cyan)
+	Grey = lipgloss.NewStyle().Foreground(t.Grey)
+	BoldBlue = Blue.Bold(true).Underline(true)
+	BoldRed = lipgloss.NewStyle().Foreground(t.Red).Bold(true)
+	BoldYellow = lipgloss.NewStyle().Foreground(t.Yellow).Bold(true)
+	Background = lipgloss.NewStyle().Background(t.Background).Bold(true)
+	Strikethrough = lipgloss.NewStyle().Foreground(t.Grey).Strikethrough(true)
+	Inserted = lipgloss.NewStyle().Foreground(t.Green).Underline(true)
+	Deleted = lipgloss.NewStyle().Foreground(t.Red).Strikethrough(true)
+}
@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"io"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cockroachdb/errors"
+	"github.com/rm-hull/git-commit-summary/internal/interfaces"
+)
+
+type commitResultMsg struct {
+	err error
+
+	// after a failed commit, the committed files changed by its hooks, and
+	// those that already had unstaged changes, which they may have changed too
+	hookChanged  []string
+	partlyStaged []string
+}
+
+// commitCommand stages (and unstages) the chosen files and then commits, with
+// the terminal released from the UI, so that git, its hooks and any
+// interactive prompts (e.g. for GPG signing) can use it directly.
+type commitCommand struct {
+	gitClient interfaces.GitClient
+	message   string
+	stage     []string
+	unstage   []string
+	committed []string
+
+	hookChanged  []string
+	partlyStaged []string
+}
+
+func (c *commitCommand) Run() error {
+	if err := c.gitClient.Unstage(c.unstage); err != nil {
+		return err
+	}
+	if err := c.gitClient.Stage(c.stage); err != nil {
+		return err
+	}
+
+	// what is unstaged before the commit tells which files its hooks changed
+	before, err := c.gitClient.UnstagedFiles(c.committed)
+	if err != nil {
+		return err
+	}
+	err = c.gitClient.Commit(c.message)
+	if err == nil {
+		return nil
+	}
+
+	after, unstagedErr := c.gitClient.UnstagedFiles(c.committed)
+	if unstagedErr != nil {
+		return errors.CombineErrors(err, unstagedErr)
+	}
+	for _, file := range after {
+		if slices.Contains(before, file) {
+			c.partlyStaged = append(c.partlyStaged, file)
+		} else {
+			c.hookChanged = append(c.hookChanged, file)
+		}
+	}
+	return err
+}
+
+// The git client always uses the process's standard streams
+func (c *commitCommand) SetStdin(io.Reader)  {}
+func (c *commitCommand) SetStdout(io.Writer) {}
+func (c *commitCommand) SetStderr(io.Writer) {}
+
+func (m *Model) commit() tea.Cmd {
+	c := m.commitCommand()
+	return tea.Exec(c, func(err error) tea.Msg {
+		return commitResultMsg{err: err, hookChanged: c.hookChanged, partlyStaged: c.partlyStaged}
+	})
+}
+
+// commitCommand commits the message. The pending files are staged again, in
+// case they were changed since, except for those changed by the hooks of a
+// failed commit: they stay as they were staged then, unless re-staged.
+func (m *Model) commitCommand() *commitCommand {
+	committed := m.stagedFiles
+	if m.selectedFiles != nil {
+		committed = m.selectedFiles
+	}
+
+	stage := slices.DeleteFunc(slices.Clone(m.pendingFiles), func(file string) bool {
+		return slices.Contains(m.hookChanged, file)
+	})
+	return &commitCommand{
+		gitClient: m.gitClient,
+		message:   m.commitMessage,
+		stage:     stage,
+		unstage:   m.excludedFiles,
+		committed: append(slices.Clone(committed), m.pendingFiles...),
+	}
+}
+
+// restage stages the changes made by a failed pre-commit hook, such as a
+// formatter, to the committed files. A file that also has unstaged changes
+// of its own is left alone, as those would be staged along with the hook's.
+func (m *Model) restage() tea.Msg {
+	if err := m.gitClient.Stage(m.hookChanged); err != nil {
+		return errMsg{err}
+	}
+	return restagedMsg{}
+}
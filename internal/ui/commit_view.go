@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
@@ -11,6 +12,16 @@ import (
 	"github.com/charmbracelet/glamour/styles"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/cockroachdb/errors"
+	"github.com/rm-hull/git-commit-summary/internal/keys"
+	"github.com/rm-hull/git-commit-summary/internal/message"
+)
+
+const (
+	messageWidth     = 72 // the conventional width of a commit message
+	defaultMaxHeight = 15 // used until the size of the terminal is known
+	minWidth         = 20
+	minHeight        = 2
+	minPaneWidth     = 40 // below which the diff pane goes under the message
 )
 
 type commitViewModel struct {
@@ -21,24 +32,42 @@ type commitViewModel struct {
 	preview  bool
 	helpText bool
 	renderer *glamour.TermRenderer
+	plain    bool // preview the message as it is, rather than rendering its Markdown
+	diffing  bool
+	keys     keys.Map
+
+	// subjectWidth is the width beyond which the subject is flagged, or zero
+	// for the default
+	subjectWidth int
+
+	showingHelp bool
+
+	// generations is nil when there are no other versions to cycle through,
+	// e.g. when splitting
+	generations *Generations
+	generation  int
+
+	// trailers is nil when trailers are not offered, e.g. when splitting
+	trailers        *trailerViewModel
+	editingTrailers bool
+
+	// stagedDiff is nil when there is no diff to show beside the message
+	stagedDiff        *stagedDiffViewModel
+	viewingStagedDiff bool
+	stackedPane       bool
+
+	// the size of the terminal, or zero until it is known
+	width  int
+	height int
 }
 
-func initialCommitViewModel(message string) (*commitViewModel, error) {
+func initialCommitViewModel(message string, plain bool, keyMap keys.Map) (*commitViewModel, error) {
 	ta := textarea.New()
 	ta.CharLimit = 0
 	ta.ShowLineNumbers = false
 	ta.Prompt = ""
-
-	height := 2
-	messageLines := strings.Count(message, "\n") + 1
-	if height < messageLines {
-		height = messageLines
-	}
-	if height > 15 {
-		height = 15
-	}
-	ta.SetHeight(height)
-	ta.SetWidth(72 + 2) // +2 is to accommodate for horizontal padding
+	ta.SetWidth(messageWidth + 2) // +2 is to accommodate for horizontal padding
+	ta.SetHeight(min(max(visualLines(message, messageWidth), minHeight), defaultMaxHeight))
 	ta.SetValue(message)
 	if message == "" {
 		ta.Placeholder = "Unable to provide a commit summary: staged files may be too large to\nbe summarized or were excluded from the visible diff."
@@ -51,6 +80,9 @@ func initialCommitViewModel(message string) (*commitViewModel, error) {
 	vp := viewport.New(ta.Width(), ta.Height())
 
 	customStyle := styles.DarkStyleConfig
+	if style, ok := styles.DefaultStyles[activeTheme.Glamour]; ok {
+		customStyle = *style
+	}
 	customStyle.Document.Margin = uintPtr(0)
 	customStyle.H2.BlockSuffix = ""
 	renderer, err := glamour.NewTermRenderer(
@@ -66,11 +98,13 @@ func initialCommitViewModel(message string) (*commitViewModel, error) {
 		viewport: vp,
 		history:  NewHistory(message),
 		boxStyle: lipgloss.NewStyle().
-			BorderForeground(lipgloss.Color("6")). // Cyan
+			BorderForeground(activeTheme.Cyan).
 			Padding(0, 1),
 		preview:  false,
 		helpText: true,
+		keys:     keyMap,
 		renderer: renderer,
+		plain:    plain,
 	}, nil
 }
 
@@ -87,33 +121,79 @@ func (m *commitViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.Type {
-		case tea.KeyCtrlX:
+		if m.showingHelp {
+			// any key closes the help
+			m.showingHelp = false
+			return m, nil
+		}
+
+		if m.editingTrailers && msg.Type != tea.KeyCtrlC {
+			done, cmd := m.trailers.Update(msg)
+			if done || key.Matches(msg, m.keys.Trailers) {
+				m.editingTrailers = false
+				cmd = m.textarea.Focus()
+			}
+			return m, cmd
+		}
+
+		if m.viewingStagedDiff && msg.Type != tea.KeyCtrlC {
+			done, cmd := m.stagedDiff.Update(msg)
+			if done || key.Matches(msg, m.keys.Changes) {
+				m.viewingStagedDiff = false
+				cmd = m.textarea.Focus()
+			}
+			return m, cmd
+		}
+
+		switch {
+		case key.Matches(msg, m.keys.Commit):
 			m.helpText = false
 			m.textarea.Blur()
-			return m, func() tea.Msg { return commitMsg(m.textarea.Value()) }
+			return m, func() tea.Msg { return commitMsg(m.fullMessage()) }
+
+		case key.Matches(msg, m.keys.Help) && (msg.Type != tea.KeyRunes || !m.textarea.Focused()):
+			// a printable key is typed into the message while editing it
+			m.showingHelp = true
+			return m, nil
+
+		case key.Matches(msg, m.keys.Trailers):
+			if m.trailers != nil && !m.preview {
+				m.editingTrailers = true
+				m.textarea.Blur()
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Changes):
+			if m.stagedDiff != nil && !m.preview && !m.diffing {
+				// open at the changes to the file mentioned on the cursor's line
+				m.stagedDiff.jumpTo(m.cursorLine())
+				m.viewingStagedDiff = true
+				m.textarea.Blur()
+			}
+			return m, nil
 
-		case tea.KeyCtrlR:
+		case key.Matches(msg, m.keys.Regenerate):
 			m.helpText = false
 			m.textarea.Blur()
 			return m, func() tea.Msg { return regenerateMsg{} }
 
-		case tea.KeyCtrlC, tea.KeyEsc:
-			if m.preview && msg.Type == tea.KeyEsc {
-				m.preview = false
-				m.textarea.Focus()
-				return m, nil
-			}
+		case msg.Type == tea.KeyEsc && (m.preview || m.diffing):
+			m.preview, m.diffing = false, false
+			m.textarea.Focus()
+			return m, nil
+
+		case msg.Type == tea.KeyCtrlC, key.Matches(msg, m.keys.Abort):
 			m.helpText = false
 			m.textarea.Blur()
 			return m, func() tea.Msg { return abortMsg{} }
 
-		case tea.KeyCtrlP:
+		case key.Matches(msg, m.keys.Preview):
+			m.diffing = false
 			if m.preview {
 				m.textarea.Focus()
 			} else {
 				m.textarea.Blur()
-				out, err := m.renderer.Render(m.textarea.Value())
+				out, err := m.render()
 				if err != nil {
 					message := fmt.Sprintf("%s:\n%v", BoldRed.Render("Error rendering preview:"), err)
 					m.viewport.SetContent(message)
@@ -122,35 +202,61 @@ func (m *commitViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 			m.preview = !m.preview
+			m.layout()
 			return m, nil
 
+		case key.Matches(msg, m.keys.Compare):
+			if m.generations == nil {
+				return m, nil
+			}
+			m.preview = false
+			if m.diffing {
+				m.textarea.Focus()
+			} else {
+				m.textarea.Blur()
+				m.viewport.SetContent(renderDiff(wordDiff(m.previousVersion(), m.textarea.Value())))
+			}
+			m.diffing = !m.diffing
+			m.layout()
+			return m, nil
 		}
 
-		if m.preview {
+		if m.preview || m.diffing {
 			m.viewport, cmd = m.viewport.Update(msg)
 			cmds = append(cmds, cmd)
 			return m, tea.Batch(cmds...)
 		}
 
-		switch msg.Type {
-		case tea.KeyCtrlZ:
+		switch {
+		case key.Matches(msg, m.keys.Undo):
 			if value, ok := m.history.Undo(); ok {
 				m.textarea.SetValue(value)
+				m.layout()
 			}
 			return m, nil
 
-		case tea.KeyCtrlY:
+		case key.Matches(msg, m.keys.Redo):
 			if value, ok := m.history.Redo(); ok {
 				m.textarea.SetValue(value)
+				m.layout()
 			}
 			return m, nil
 
-		case tea.KeyCtrlK:
+		case key.Matches(msg, m.keys.PreviousVersion):
+			m.showGeneration(m.generation - 1)
+			return m, nil
+
+		case key.Matches(msg, m.keys.NextVersion):
+			m.showGeneration(m.generation + 1)
+			return m, nil
+
+		case key.Matches(msg, m.keys.Clear):
 			if m.textarea.Value() == "" {
 				return m, nil
 			}
 			m.history.Add("")
 			m.textarea.SetValue("")
+			m.layout()
 			return m, nil
 
 		default:
@@ -170,54 +276,265 @@ func (m *commitViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	newValue := m.textarea.Value()
 	if oldValue != newValue {
 		m.history.Add(newValue)
+		m.layout()
 	}
 
 	return m, tea.Batch(cmds...)
 }
 
+// setSize fits the view to a terminal of the given size.
+func (m *commitViewModel) setSize(width, height int) {
+	m.width, m.height = width, height
+	m.layout()
+}
+
+// layout sizes the editor to fit the message, keeping to the conventional
+// width unless the terminal is narrower, and gives the diff pane the space
+// left beside it, or below it when there is too little.
+func (m *commitViewModel) layout() {
+	width := messageWidth + 2
+	maxHeight := defaultMaxHeight
+	if m.width > 0 {
+		width = max(min(width, m.width-4), minWidth) // -4 for the border and padding
+	}
+	if m.height > 0 {
+		// leaving room for the border, the help line and the trailers
+		chrome := 4
+		if m.trailers != nil {
+			chrome += len(m.trailers.Selected())
+		}
+		if m.subjectWarning() != "" {
+			chrome++
+		}
+		maxHeight = max(m.height-chrome, minHeight)
+	}
+
+	height := min(max(visualLines(m.textarea.Value(), width-2), minHeight), maxHeight)
+	m.textarea.SetWidth(width)
+	m.textarea.SetHeight(height)
+	m.viewport.Width = width
+	m.viewport.Height = min(max(height, m.viewport.TotalLineCount()), maxHeight)
+
+	if m.stagedDiff == nil || m.width == 0 {
+		return
+	}
+	side := m.width - (width + 4) - 4
+	m.stackedPane = side < minPaneWidth
+	if m.stackedPane {
+		m.stagedDiff.setSize(width, max(maxHeight-height-2, minHeight))
+	} else {
+		m.stagedDiff.setSize(side, maxHeight)
+	}
+}
+
+// resize fits the view, if it is a commit view, to the terminal, less the
+// lines shown above it.
+func resize(view tea.Model, width, height int) {
+	if commitView, ok := view.(*commitViewModel); ok && width > 0 {
+		commitView.setSize(width, height)
+	}
+}
+
+// boxWidth is the width of a box showing a commit message, within its border:
+// the conventional width, or narrower should the terminal be.
+func boxWidth(terminalWidth int) int {
+	if terminalWidth <= 0 {
+		return messageWidth + 2
+	}
+	return max(min(messageWidth+2, terminalWidth-2), minWidth)
+}
+
+// visualLines counts the lines the text takes up once wrapped to the width.
+func visualLines(text string, width int) int {
+	count := 0
+	for _, line := range strings.Split(text, "\n") {
+		count += max(1, (lipgloss.Width(line)+width-1)/max(width, 1))
+	}
+	return count
+}
+
+// fitWidth wraps the text, such as a help line, to the width of the terminal
+// when that is known.
+func fitWidth(text string, width int) string {
+	if width <= 0 {
+		return text
+	}
+	return lipgloss.NewStyle().Width(width).Render(text)
+}
+
+// addGeneration adds the message being edited to the versions generated over
+// the session, which may then be cycled through.
+func (m *commitViewModel) addGeneration(generations *Generations) {
+	m.generations = generations
+	m.generation = generations.Add(m.history)
+}
+
+// showGeneration switches to editing another version of the message, as it
+// was last left.
+func (m *commitViewModel) showGeneration(index int) {
+	if m.generations == nil || index < 0 || index >= m.generations.Len() {
+		return
+	}
+	m.generation = index
+	m.history = m.generations.At(index)
+	m.textarea.SetValue(m.history.Value())
+	m.layout()
+}
+
+// previousVersion is what the diff view compares the message being edited
+// with: the version generated before it, or for the first, the message as it
+// was generated.
+func (m *commitViewModel) previousVersion() string {
+	if m.generation == 0 {
+		return m.history.Initial()
+	}
+	return m.generations.At(m.generation - 1).Value()
+}
+
+// cursorLine is the line of the message the cursor is on.
+func (m *commitViewModel) cursorLine() string {
+	lines := strings.Split(m.textarea.Value(), "\n")
+	if row := m.textarea.Line(); row < len(lines) {
+		return lines[row]
+	}
+	return ""
+}
+
+// render renders the message for the preview.
+func (m *commitViewModel) render() (string, error) {
+	if m.plain {
+		return m.fullMessage(), nil
+	}
+	return m.renderer.Render(m.fullMessage())
+}
+
+// fullMessage is the edited commit message, with the selected trailers appended.
+func (m *commitViewModel) fullMessage() string {
+	if m.trailers == nil {
+		return m.textarea.Value()
+	}
+	return message.AppendTrailers(m.textarea.Value(), m.trailers.Selected()...)
+}
+
 func (m *commitViewModel) View() string {
 	var view string
 	var title string
 
-	if m.preview {
+	switch {
+	case m.preview:
 		view = m.viewport.View()
 		title = " Commit message [preview] "
-	} else {
+	case m.diffing && m.generation == 0:
+		view = m.viewport.View()
+		title = " Commit message [changes since generated] "
+	case m.diffing:
+		view = m.viewport.View()
+		title = fmt.Sprintf(" Commit message [changes since version %d] ", m.generation)
+	default:
 		view = m.textarea.View()
 		title = " Commit message "
+		if m.trailers != nil {
+			for _, trailer := range m.trailers.Selected() {
+				view += "\n" + Cyan.Render(trailer.String())
+			}
+		}
+		if m.generations != nil && m.generations.Len() > 1 {
+			title = fmt.Sprintf(" Commit message [version %d of %d] ", m.generation+1, m.generations.Len())
+		}
 	}
 
 	titleBorder := lipgloss.RoundedBorder()
 	titleBorder.Top = title + strings.Repeat(
-		"─", m.textarea.Width()-lipgloss.Width(title)+2) // +2 is to accommodate for horizontal padding
+		"─", max(m.textarea.Width()-lipgloss.Width(title)+2, 0)) // +2 is to accommodate for horizontal padding
 
-	return m.boxStyle.
-		BorderStyle(titleBorder).
-		Render(view) + "\n" + m.helpTextView()
+	if m.editingTrailers {
+		return m.boxStyle.
+			BorderStyle(titleBorder).
+			Render(view) + "\n" + m.trailers.View()
+	}
+
+	if m.viewingStagedDiff {
+		box := m.boxStyle.BorderStyle(titleBorder).Render(view)
+		if m.stackedPane {
+			box = lipgloss.JoinVertical(lipgloss.Left, box, m.stagedDiff.View())
+		} else {
+			box = lipgloss.JoinHorizontal(lipgloss.Top, box, m.stagedDiff.View())
+		}
+		return box + "\n" + fitWidth(m.stagedDiff.helpTextView(), m.width)
+	}
+
+	box := m.boxStyle.BorderStyle(titleBorder).Render(view)
+	if warning := m.subjectWarning(); warning != "" && !m.preview && !m.diffing {
+		box += "\n" + fitWidth(warning, m.width)
+	}
+	return box + "\n" + fitWidth(m.helpTextView(), m.width)
+}
+
+// subjectWarning flags a subject wider than it should be, which is left as it
+// is for the author to shorten.
+func (m *commitViewModel) subjectWarning() string {
+	layout := message.Layout{SubjectWidth: m.subjectWidth}
+	width, long := layout.LongSubject(m.textarea.Value())
+	if !long {
+		return ""
+	}
+	limit := m.subjectWidth
+	if limit <= 0 {
+		limit = message.DefaultSubjectWidth
+	}
+	return BoldRed.Render(fmt.Sprintf("The subject is %d characters long, over the %d it should be.", width, limit))
 }
 
 func (m *commitViewModel) helpTextView() string {
 	if !m.helpText {
 		return ""
 	}
+	if m.showingHelp {
+		return fullHelpView(m.keys)
+	}
+
+	back := BoldYellow.Render("ESC") + ":back"
+	if m.diffing {
+		return helpLine(
+			helpEntry(m.keys.Commit, true),
+			helpEntry(m.keys.Regenerate, true),
+			helpEntry(m.keys.Preview, true),
+			helpEntry(m.keys.Help, true),
+			back)
+	}
 
 	if m.preview {
-		return fmt.Sprintf("%s:commit %s:clear %s:undo %s:regen %s:editor  %s:back",
-			BoldYellow.Render("CTRL+X"),
-			Strikethrough.Render("CTRL+K"),
-			Strikethrough.Render("CTRL+Z"),
-			BoldYellow.Render("CTRL+R"),
-			BoldYellow.Render("CTRL+P"),
-			BoldYellow.Render("ESC"))
-	}
-
-	return fmt.Sprintf("%s:commit %s:clear %s:undo %s:regen %s:preview %s:abort",
-		BoldYellow.Render("CTRL+X"),
-		BoldYellow.Render("CTRL+K"),
-		BoldYellow.Render("CTRL+Z"),
-		BoldYellow.Render("CTRL+R"),
-		BoldYellow.Render("CTRL+P"),
-		BoldYellow.Render("ESC"))
+		return helpLine(
+			helpEntry(m.keys.Commit, true),
+			helpEntry(m.keys.Clear, false),
+			helpEntry(m.keys.Undo, false),
+			helpEntry(m.keys.Regenerate, true),
+			helpKey(m.keys.Preview)+":editor",
+			helpEntry(m.keys.Help, true),
+			back)
+	}
+
+	entries := []string{
+		helpEntry(m.keys.Commit, true),
+		helpEntry(m.keys.Clear, true),
+		helpEntry(m.keys.Undo, true),
+		helpEntry(m.keys.Regenerate, true),
+		helpEntry(m.keys.Preview, true),
+	}
+	if m.trailers != nil {
+		entries = append(entries, helpEntry(m.keys.Trailers, true))
+	}
+	if m.generations != nil {
+		if m.generations.Len() > 1 {
+			entries = append(entries, helpKey(m.keys.PreviousVersion)+"/"+helpKey(m.keys.NextVersion)+":versions")
+		}
+		entries = append(entries, helpEntry(m.keys.Compare, true))
+	}
+	if m.stagedDiff != nil {
+		entries = append(entries, helpEntry(m.keys.Changes, true))
+	}
+	entries = append(entries, helpEntry(m.keys.Help, true), helpEntry(m.keys.Abort, true))
+	return helpLine(entries...)
 }
 
 func uintPtr(v uint) *uint { return &v }
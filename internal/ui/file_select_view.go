@@ -0,0 +1,165 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/rm-hull/git-commit-summary/internal/interfaces"
+)
+
+const maxFilesShown = 15
+
+type fileSelectViewModel struct {
+	files    []interfaces.FileChange
+	selected []bool
+	cursor   int
+	offset   int
+}
+
+func initialFileSelectViewModel(files []interfaces.FileChange) *fileSelectViewModel {
+	selected := make([]bool, len(files))
+	for i := range selected {
+		selected[i] = true
+	}
+
+	return &fileSelectViewModel{
+		files:    files,
+		selected: selected,
+	}
+}
+
+func (m *fileSelectViewModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *fileSelectViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+
+		case "down", "j":
+			if m.cursor < len(m.files)-1 {
+				m.cursor++
+			}
+
+		case " ", "x":
+			m.selected[m.cursor] = !m.selected[m.cursor]
+
+		case "a":
+			all := m.countSelected() < len(m.files)
+			for i := range m.selected {
+				m.selected[i] = all
+			}
+
+		case "enter":
+			if m.countSelected() == 0 {
+				return m, nil
+			}
+			return m, func() tea.Msg { return m.result() }
+
+		case "ctrl+c", "esc":
+			return m, func() tea.Msg { return abortMsg{} }
+		}
+
+	case errMsg:
+		return m, tea.Quit
+	}
+
+	m.scrollToCursor()
+	return m, nil
+}
+
+func (m *fileSelectViewModel) View() string {
+	var sb strings.Builder
+	sb.WriteString(Magenta.Render(fmt.Sprintf(
+		"Select the files to summarize and commit (%d of %d selected):",
+		m.countSelected(), len(m.files))) + "\n")
+
+	end := min(m.offset+maxFilesShown, len(m.files))
+	for i := m.offset; i < end; i++ {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = Cyan.Render("❯ ")
+		}
+
+		checkbox := "[ ]"
+		file := Strikethrough.Render(fileLabel(m.files[i]))
+		if m.selected[i] {
+			checkbox = "[x]"
+			file = fileLabel(m.files[i])
+		}
+
+		sb.WriteString(fmt.Sprintf("%s%s %s\n", cursor, checkbox, file))
+	}
+
+	if hidden := len(m.files) - end + m.offset; hidden > 0 {
+		sb.WriteString(Cyan.Render(fmt.Sprintf("  (%d more, scroll to see)", hidden)) + "\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("%s:toggle %s:all %s:confirm %s:abort",
+		BoldYellow.Render("SPACE"),
+		BoldYellow.Render("A"),
+		BoldYellow.Render("ENTER"),
+		BoldYellow.Render("ESC")))
+
+	return sb.String()
+}
+
+func (m *fileSelectViewModel) scrollToCursor() {
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+maxFilesShown {
+		m.offset = m.cursor - maxFilesShown + 1
+	}
+}
+
+func (m *fileSelectViewModel) countSelected() int {
+	count := 0
+	for _, selected := range m.selected {
+		if selected {
+			count++
+		}
+	}
+	return count
+}
+
+// fileLabel shows where a renamed or copied file came from.
+func fileLabel(file interfaces.FileChange) string {
+	if file.OldPath == "" {
+		return file.Path
+	}
+	return file.OldPath + " → " + file.Path
+}
+
+// result lists the paths selected and excluded. A renamed file's original
+// path goes with it either way, as its removal is part of the rename. A copied
+// file's original is selected along with it, so that the copy is seen as
+// such, but is otherwise left alone, as it may have changes of its own.
+func (m *fileSelectViewModel) result() filesSelectedMsg {
+	var result filesSelectedMsg
+	for i, file := range m.files {
+		if m.selected[i] {
+			result.selected = append(result.selected, file.Path)
+			if file.OldPath != "" && !slices.Contains(result.selected, file.OldPath) {
+				result.selected = append(result.selected, file.OldPath)
+			}
+		} else {
+			result.excluded = append(result.excluded, file.Path)
+			if file.Status == "R" {
+				result.excluded = append(result.excluded, file.OldPath)
+			}
+		}
+	}
+	// unless the copied file was itself excluded
+	result.selected = slices.DeleteFunc(result.selected, func(path string) bool {
+		return slices.Contains(result.excluded, path)
+	})
+	return result
+}
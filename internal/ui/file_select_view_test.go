@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rm-hull/git-commit-summary/internal/interfaces"
+)
+
+func TestFileSelectViewModel(t *testing.T) {
+	files := func(paths ...string) []interfaces.FileChange {
+		changes := make([]interfaces.FileChange, len(paths))
+		for i, path := range paths {
+			changes[i] = interfaces.FileChange{Status: "M", Path: path}
+		}
+		return changes
+	}
+
+	keys := func(m *fileSelectViewModel, keys ...string) tea.Cmd {
+		var cmd tea.Cmd
+		for _, k := range keys {
+			msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+			switch k {
+			case "down":
+				msg = tea.KeyMsg{Type: tea.KeyDown}
+			case "enter":
+				msg = tea.KeyMsg{Type: tea.KeyEnter}
+			case "space":
+				msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+			}
+			_, cmd = m.Update(msg)
+		}
+		return cmd
+	}
+
+	t.Run("All files selected initially", func(t *testing.T) {
+		m := initialFileSelectViewModel(files("a.go", "b.go"))
+		cmd := keys(m, "enter")
+		assert.Equal(t, filesSelectedMsg{selected: []string{"a.go", "b.go"}}, cmd())
+	})
+
+	t.Run("Deselect a file", func(t *testing.T) {
+		m := initialFileSelectViewModel(files("a.go", "b.go", "c.go"))
+		cmd := keys(m, "down", "space", "enter")
+		assert.Equal(t, filesSelectedMsg{
+			selected: []string{"a.go", "c.go"},
+			excluded: []string{"b.go"},
+		}, cmd())
+	})
+
+	t.Run("Renamed file goes with its original path", func(t *testing.T) {
+		changes := []interfaces.FileChange{
+			{Status: "R", OldPath: "a.txt", Path: "b.txt"},
+			{Status: "M", Path: "c.go"},
+		}
+
+		m := initialFileSelectViewModel(changes)
+		assert.Contains(t, m.View(), "a.txt → b.txt")
+		assert.Equal(t, filesSelectedMsg{selected: []string{"b.txt", "a.txt", "c.go"}}, keys(m, "enter")())
+
+		m = initialFileSelectViewModel(changes)
+		assert.Equal(t, filesSelectedMsg{
+			selected: []string{"c.go"},
+			excluded: []string{"b.txt", "a.txt"},
+		}, keys(m, "space", "enter")())
+	})
+
+	t.Run("Copied file is seen as a copy, but its original is left alone", func(t *testing.T) {
+		changes := []interfaces.FileChange{
+			{Status: "M", Path: "a.go"},
+			{Status: "C", OldPath: "a.go", Path: "b.go"},
+		}
+
+		m := initialFileSelectViewModel(changes)
+		assert.Equal(t, filesSelectedMsg{selected: []string{"a.go", "b.go"}}, keys(m, "enter")())
+
+		m = initialFileSelectViewModel(changes)
+		assert.Equal(t, filesSelectedMsg{selected: []string{"a.go"}, excluded: []string{"b.go"}}, keys(m, "down", "space", "enter")())
+
+		m = initialFileSelectViewModel(changes)
+		assert.Equal(t, filesSelectedMsg{selected: []string{"b.go"}, excluded: []string{"a.go"}}, keys(m, "space", "enter")())
+	})
+
+	t.Run("Cannot confirm an empty selection", func(t *testing.T) {
+		m := initialFileSelectViewModel(files("a.go", "b.go"))
+		cmd := keys(m, "a", "enter")
+		assert.Nil(t, cmd)
+		assert.Equal(t, 0, m.countSelected())
+	})
+
+	t.Run("Escape aborts", func(t *testing.T) {
+		m := initialFileSelectViewModel(files("a.go"))
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		assert.Equal(t, abortMsg{}, cmd())
+	})
+}
@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/rm-hull/git-commit-summary/internal/keys"
+)
+
+// helpKey is how a binding's keys are shown in the help, e.g. CTRL+X.
+func helpKey(b key.Binding) string {
+	return BoldYellow.Render(strings.ToUpper(b.Help().Key))
+}
+
+// helpEntry describes a binding for the help line, its keys struck through
+// when it does not apply to the current view.
+func helpEntry(b key.Binding, enabled bool) string {
+	if !enabled {
+		return Strikethrough.Render(strings.ToUpper(b.Help().Key)) + ":" + b.Help().Desc
+	}
+	return helpKey(b) + ":" + b.Help().Desc
+}
+
+func helpLine(entries ...string) string {
+	return strings.Join(entries, " ")
+}
+
+// fullHelpColumns is how many groups of the full help are shown side by side.
+const fullHelpColumns = 3
+
+// fullHelpView lists every key binding, in a column for each group.
+func fullHelpView(keyMap keys.Map) string {
+	var rows, columns []string
+	for i, group := range keyMap.FullHelp() {
+		if i > 0 && i%fullHelpColumns == 0 {
+			rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, columns...))
+			columns = nil
+		}
+		var keyColumn, descColumn []string
+		for _, b := range group.Bindings {
+			keyColumn = append(keyColumn, helpKey(b))
+			descColumn = append(descColumn, b.Help().Desc)
+		}
+		columns = append(columns, lipgloss.JoinVertical(lipgloss.Left,
+			Magenta.Render(group.Title),
+			lipgloss.JoinHorizontal(lipgloss.Top,
+				strings.Join(keyColumn, "\n"), "  ", strings.Join(descColumn, "\n"), "    "),
+		))
+	}
+
+	rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, columns...))
+
+	return strings.Join(rows, "\n\n") + "\n" +
+		Cyan.Render("Press any key to close the help.")
+}
@@ -39,3 +39,30 @@ func (h *History) Add(value string) {
 func (h *History) Value() string {
 	return h.stack[h.index]
 }
+
+// Initial is the value the history started from, e.g. the message as it was
+// generated, before any edits.
+func (h *History) Initial() string {
+	return h.stack[0]
+}
+
+// Generations are the versions of the commit message produced over a session,
+// one for each answer from the LLM, along with the History of the edits made
+// to each, so that an earlier version is not lost by regenerating.
+type Generations struct {
+	versions []*History
+}
+
+// Add adds the history of a new version, returning its index.
+func (g *Generations) Add(h *History) int {
+	g.versions = append(g.versions, h)
+	return len(g.versions) - 1
+}
+
+func (g *Generations) At(index int) *History {
+	return g.versions[index]
+}
+
+func (g *Generations) Len() int {
+	return len(g.versions)
+}
@@ -131,3 +131,16 @@ func TestHistory(t *testing.T) {
 		assert.Equal(t, "3", val)
 	})
 }
+
+func TestGenerations(t *testing.T) {
+	g := &Generations{}
+	first := NewHistory("feat: first")
+	first.Add("feat: first, edited")
+	assert.Equal(t, 0, g.Add(first))
+	assert.Equal(t, 1, g.Add(NewHistory("feat: second")))
+
+	assert.Equal(t, 2, g.Len())
+	assert.Equal(t, "feat: first, edited", g.At(0).Value())
+	assert.Equal(t, "feat: first", g.At(0).Initial())
+	assert.Equal(t, "feat: second", g.At(1).Value())
+}
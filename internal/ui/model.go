@@ -3,28 +3,36 @@ package ui
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/cockroachdb/errors"
-	"github.com/galactixx/stringwrap"
+	"github.com/rm-hull/git-commit-summary/internal/analysis"
+	"github.com/rm-hull/git-commit-summary/internal/cache"
+	"github.com/rm-hull/git-commit-summary/internal/config"
 	"github.com/rm-hull/git-commit-summary/internal/interfaces"
 	llmprovider "github.com/rm-hull/git-commit-summary/internal/llm_provider"
+	"github.com/rm-hull/git-commit-summary/internal/message"
+	"github.com/rm-hull/git-commit-summary/internal/prompt"
+	"github.com/rm-hull/git-commit-summary/internal/session"
 )
 
 type sessionState int
 
 const (
 	showSpinner sessionState = iota
+	showFileSelect
 	showCommitView
 	showRegeneratePrompt
+	showRestorePrompt
+	showCommitFailure
 )
 
 type (
-	gitCheckMsg          []string
-	gitDiffMsg           string
+	gitCheckMsg          []interfaces.FileChange
 	llmResultMsg         string
 	commitMsg            string
 	errMsg               struct{ err error }
@@ -32,8 +40,27 @@ type (
 	regenerateMsg        struct{}
 	cancelRegenPromptMsg struct{}
 	userResponseMsg      string
+	restagedMsg          struct{}
 )
 
+type trailerSuggestionsMsg struct {
+	identity  string
+	coAuthors []string
+}
+
+type filesSelectedMsg struct {
+	selected []string
+	excluded []string
+}
+
+type gitDiffMsg struct {
+	diff     string
+	changes  []interfaces.FileChange
+	pending  []string
+	commits  []interfaces.Commit
+	breaking []analysis.Change
+}
+
 type Action int
 
 const (
@@ -47,14 +74,39 @@ type Model struct {
 	state          sessionState
 	llmProvider    llmprovider.Provider
 	gitClient      interfaces.GitClient
-	systemPrompt   string
+	cfg            *config.Config
 	userMessage    string
+	squashRange    string
+	commits        []interfaces.Commit
+	stagedFiles    []string
+	selectedFiles  []string
+	excludedFiles  []string
+	fileSelectView tea.Model
 	diff           string
+	changes        []interfaces.FileChange
+	breaking       []analysis.Change
+	pendingFiles   []string
 	spinner        spinner.Model
 	spinnerMessage string
 	commitView     tea.Model
 	commitMessage  string
 	promptView     tea.Model
+	choiceView     tea.Model
+	savedMessage   string
+	savedDiffHash  string
+	restored       bool
+	commitFailed   bool
+	failureOutput  string
+	hookChanged    []string
+	partlyStaged   []string
+	regenerate     bool
+	conversation   []llmprovider.Message // the answers and refinements following the prompt
+	generations    *Generations
+	width          int
+	height         int
+	identity       string
+	coAuthors      []string
+	cache          *cache.Cache
 	action         Action
 	err            error
 }
@@ -63,28 +115,62 @@ func InitialModel(
 	ctx context.Context,
 	llmProvider llmprovider.Provider,
 	gitClient interfaces.GitClient,
-	systemPrompt string,
+	cfg *config.Config,
 	userMessage string,
 ) *Model {
+	var responseCache *cache.Cache
+	if !cfg.Cache.Disabled && cfg.Cache.Dir != "" {
+		responseCache = cache.New(cfg.Cache.Dir, cfg.Cache.TTL, cfg.Cache.MaxSize)
+	}
+
 	return &Model{
 		ctx:            ctx,
 		state:          showSpinner,
 		llmProvider:    llmProvider,
 		gitClient:      gitClient,
-		systemPrompt:   systemPrompt,
+		cfg:            cfg,
 		userMessage:    userMessage,
 		spinner:        spinner.New(spinner.WithSpinner(spinner.MiniDot)),
 		spinnerMessage: Magenta.Render("Running git commands to determine staged changes..."),
+		cache:          responseCache,
+		generations:    &Generations{},
 		action:         None,
 	}
 }
 
+// InitialSquashModel summarizes the already staged result of squashing the
+// commits in the given range, using their messages and net diff.
+func InitialSquashModel(
+	ctx context.Context,
+	llmProvider llmprovider.Provider,
+	gitClient interfaces.GitClient,
+	cfg *config.Config,
+	squashRange string,
+	userMessage string,
+) *Model {
+	m := InitialModel(ctx, llmProvider, gitClient, cfg, userMessage)
+	m.squashRange = squashRange
+	return m
+}
+
+// RestoreMessage offers a previously saved commit message, for example from a
+// commit that failed, as an alternative to generating a new one. It is only
+// offered when the staged changes hash to the same as when it was saved.
+func (m *Model) RestoreMessage(message string, diffHash string) {
+	m.savedMessage = message
+	m.savedDiffHash = diffHash
+}
+
 func (m *Model) Init() tea.Cmd {
-	return tea.Batch(m.spinner.Tick, m.checkGitStatus)
+	return tea.Batch(m.spinner.Tick, m.checkGitStatus, m.suggestTrailers)
 }
 
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.resize()
+
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyCtrlC:
@@ -95,65 +181,140 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case gitCheckMsg:
+		if len(msg) == 0 && m.squashRange != "" {
+			m.err = errors.New("no changes are staged, run `git merge --squash` or `git reset --soft` first")
+			return m, tea.Quit
+		}
 		if len(msg) == 0 {
 			m.err = errors.New("no changes are staged")
 			return m, tea.Quit
 		}
+		m.stagedFiles = stagedPaths(msg)
+		if m.cfg.SelectFiles && m.squashRange == "" {
+			m.state = showFileSelect
+			m.fileSelectView = initialFileSelectViewModel(msg)
+			return m, m.fileSelectView.Init()
+		}
 		return m, m.getGitDiff
 
+	case filesSelectedMsg:
+		m.state = showSpinner
+		m.selectedFiles = msg.selected
+		m.excludedFiles = msg.excluded
+		return m, tea.Batch(m.spinner.Tick, m.getGitDiff)
+
 	case gitDiffMsg:
+		m.diff = msg.diff
+		m.changes = msg.changes
+		m.breaking = msg.breaking
+		m.pendingFiles = msg.pending
+		m.commits = msg.commits
+		if m.regenerate {
+			// the staged changes were altered by a failed pre-commit hook, so
+			// the conversation about the previous changes is forgotten
+			m.regenerate = false
+			m.conversation = nil
+			return m, m.generateSummary(false)
+		}
 		m.spinnerMessage = fmt.Sprintf("%s%s%s",
 			Blue.Render("Generating commit summary (using: "),
 			BoldBlue.Render(m.llmProvider.Model()),
 			Blue.Render(")"),
 		)
-		m.diff = string(msg)
-		return m, m.generateSummary(m.diff, "")
+		if m.savedMessage != "" && m.savedDiffHash == m.DiffHash() {
+			m.state = showRestorePrompt
+			m.choiceView = initialChoiceViewModel(
+				Magenta.Render("A commit message from a previous, unfinished commit was found:")+
+					"\n\n"+m.savedMessage+"\n",
+				choice{key: "r", label: "restore"},
+				choice{key: "g", label: "generate new"},
+				choice{key: "esc", label: "abort"},
+			)
+			return m, m.choiceView.Init()
+		}
+		return m, m.generateSummary(true)
+
+	case trailerSuggestionsMsg:
+		m.identity = msg.identity
+		m.coAuthors = msg.coAuthors
+		if commitView, ok := m.commitView.(*commitViewModel); ok {
+			commitView.trailers.suggest(m.identity, m.cfg.SignOff, m.coAuthors)
+		}
+		return m, nil
+
+	case choiceMsg:
+		if m.state == showCommitFailure {
+			return m.handleCommitFailureChoice(msg)
+		}
+		switch msg {
+		case "r":
+			m.restored = true
+			return m.showCommitView(m.savedMessage)
+		case "g":
+			m.state = showSpinner
+			return m, tea.Batch(m.spinner.Tick, m.generateSummary(true))
+		default:
+			m.action = Abort
+			return m, tea.Quit
+		}
 
 	case llmResultMsg:
-		m.state = showCommitView
-		commitMessage := string(msg)
+		commitMessage := formatResponse(m.cfg, string(msg), changedPaths(m.changes))
 		if m.userMessage != "" {
 			// append the user supplied message
 			commitMessage = fmt.Sprintf("%s\n\n%s", commitMessage, m.userMessage)
 		}
-
-		// Swerve a bug in https://github.com/galactixx/stringwrap/pull/1
-		if commitMessage != "" {
-			commitMessage, _, m.err = stringwrap.StringWrap(commitMessage, 72, 4, false)
-			if m.err != nil {
-				return m, tea.Quit
-			}
-		}
-		commitMessage = strings.ReplaceAll(commitMessage, "\n\n\n", "\n\n")
-		m.commitView, m.err = initialCommitViewModel(commitMessage)
-		if m.err != nil {
-			return m, tea.Quit
+		if len(m.breaking) > 0 {
+			commitMessage = m.cfg.Style.MarkBreaking(commitMessage, analysis.Summary(m.breaking))
 		}
-		return m, m.commitView.Init()
+
+		return m.showCommitView(m.cfg.Layout.Apply(commitMessage))
 
 	case commitMsg:
-		m.action = Commit
 		m.commitMessage = string(msg)
-		return m, tea.Quit
+		return m, m.commit()
+
+	case commitResultMsg:
+		if msg.err == nil {
+			m.action = Commit
+			return m, tea.Quit
+		}
+		m.commitFailed = true
+		m.hookChanged, m.partlyStaged = msg.hookChanged, msg.partlyStaged
+		return m.showCommitFailure(msg.err)
+
+	case restagedMsg:
+		if m.regenerate {
+			m.state = showSpinner
+			m.spinnerMessage = fmt.Sprintf("%s%s%s",
+				Blue.Render("Re-generating commit summary for the re-staged changes (using: "),
+				BoldBlue.Render(m.llmProvider.Model()),
+				Blue.Render(")"),
+			)
+			return m, tea.Batch(m.spinner.Tick, m.getGitDiff)
+		}
+		return m, m.commit()
 
 	case regenerateMsg:
 		m.state = showRegeneratePrompt
 		m.promptView = initialPromptViewModel(
-			Magenta.Render("Add an optional instruction to help shape regenerating the commit summary:"),
+			conversationView(m.conversation)+
+				Magenta.Render("Add an optional instruction to help shape regenerating the commit summary:"),
 			"ENTER to confirm, or ESC to cancel.",
 		)
+		m.resize()
 
 		return m, m.promptView.Init()
 
 	case userResponseMsg:
+		m.refine(string(msg))
 		m.state = showSpinner
 		m.spinnerMessage = fmt.Sprintf("%s%s%s",
 			Blue.Render("Re-generating commit summary (using: "),
 			BoldBlue.Render(m.llmProvider.Model()),
 			Blue.Render(")"),
 		)
-		return m, tea.Batch(m.spinner.Tick, m.generateSummary(m.diff, string(msg)))
+		return m, tea.Batch(m.spinner.Tick, m.generateSummary(false))
 
 	case cancelRegenPromptMsg:
 		m.state = showCommitView
@@ -172,61 +333,362 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch m.state {
 	case showSpinner:
 		m.spinner, cmd = m.spinner.Update(msg)
+	case showFileSelect:
+		m.fileSelectView, cmd = m.fileSelectView.Update(msg)
 	case showCommitView:
 		m.commitView, cmd = m.commitView.Update(msg)
 	case showRegeneratePrompt:
 		m.promptView, cmd = m.promptView.Update(msg)
+	case showRestorePrompt, showCommitFailure:
+		m.choiceView, cmd = m.choiceView.Update(msg)
 	}
 	return m, cmd
 }
 
+const maxFailureOutputLines = 20
+
+func (m *Model) showCommitFailure(err error) (tea.Model, tea.Cmd) {
+	m.state = showCommitFailure
+
+	output := err.Error()
+	var commitErr *interfaces.CommitError
+	if errors.As(err, &commitErr) && strings.TrimSpace(commitErr.Output) != "" {
+		output = commitErr.Output
+	}
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) > maxFailureOutputLines {
+		lines = append([]string{"..."}, lines[len(lines)-maxFailureOutputLines:]...)
+	}
+	m.failureOutput = strings.Join(lines, "\n")
+
+	var sb strings.Builder
+	sb.WriteString(BoldRed.Render("The commit failed:") + "\n\n" + m.failureOutput + "\n")
+	if len(m.hookChanged) > 0 {
+		sb.WriteString("\n" + Magenta.Render("Re-staging adds the hooks' changes to: ") + strings.Join(m.hookChanged, ", ") + "\n")
+	}
+	if len(m.partlyStaged) > 0 {
+		sb.WriteString("\n" + Magenta.Render("These files have unstaged changes of their own, so are not re-staged: ") +
+			strings.Join(m.partlyStaged, ", ") + "\n" + Magenta.Render("Stage any changes the hooks made to them yourself.") + "\n")
+	}
+
+	m.choiceView = initialChoiceViewModel(
+		sb.String(),
+		choice{key: "s", label: "re-stage & retry"},
+		choice{key: "r", label: "re-stage & regenerate"},
+		choice{key: "c", label: "retry"},
+		choice{key: "e", label: "edit"},
+		choice{key: "esc", label: "abort"},
+	)
+	return m, m.choiceView.Init()
+}
+
+func (m *Model) handleCommitFailureChoice(msg choiceMsg) (tea.Model, tea.Cmd) {
+	switch msg {
+	case "s":
+		return m, m.restage
+	case "r":
+		m.regenerate = true
+		return m, m.restage
+	case "c":
+		return m, m.commit()
+	case "e":
+		m.state = showCommitView
+		if commitView, ok := m.commitView.(*commitViewModel); ok {
+			commitView.helpText = true
+		}
+		return m, m.commitView.Init()
+	default:
+		m.action = Abort
+		return m, tea.Quit
+	}
+}
+
+func (m *Model) showCommitView(commitMessage string) (tea.Model, tea.Cmd) {
+	// keep the chosen trailers when the message is regenerated
+	trailers := initialTrailerViewModel(m.cfg.Keys.TrailerPanel)
+	trailers.suggest(m.identity, m.cfg.SignOff, m.coAuthors)
+	if previous, ok := m.commitView.(*commitViewModel); ok {
+		trailers = previous.trailers
+	}
+
+	m.state = showCommitView
+	commitView, err := newCommitView(m.cfg, commitMessage)
+	if err != nil {
+		m.err = err
+		return m, tea.Quit
+	}
+	commitView.trailers = trailers
+	commitView.addGeneration(m.generations)
+	if m.diff != "" {
+		commitView.stagedDiff = initialStagedDiffViewModel(m.diff, m.cfg.Keys.ChangesPanel)
+	}
+	m.commitView = commitView
+	m.resize()
+	return m, m.commitView.Init()
+}
+
 func (m *Model) View() string {
 	switch m.state {
 	case showSpinner:
 		return m.spinner.View() + " " + m.spinnerMessage
+	case showFileSelect:
+		return m.fileSelectView.View()
 	case showCommitView:
-		return m.commitView.View()
+		return m.pendingFilesView() + m.commitView.View()
 	case showRegeneratePrompt:
-		return m.commitView.View() + m.promptView.View()
+		return m.pendingFilesView() + m.commitView.View() + m.promptView.View()
+	case showRestorePrompt, showCommitFailure:
+		return m.choiceView.View()
 	default:
 		return ""
 	}
 }
 
+// newCommitView is a commit view for editing the message, as configured.
+func newCommitView(cfg *config.Config, commitMessage string) (*commitViewModel, error) {
+	commitView, err := initialCommitViewModel(commitMessage, cfg.Format == message.FormatPlain, cfg.Keys)
+	if err != nil {
+		return nil, err
+	}
+	commitView.subjectWidth = cfg.Layout.SubjectWidth
+	return commitView, nil
+}
+
+// resize fits the commit view and prompt to the terminal, the commit view
+// below the pending files.
+func (m *Model) resize() {
+	resize(m.commitView, m.width, m.height-strings.Count(m.pendingFilesView(), "\n"))
+	if promptView, ok := m.promptView.(*promptViewModel); ok && m.width > 0 {
+		promptView.setWidth(m.width)
+	}
+}
+
+const maxPendingFilesShown = 8
+
+func (m *Model) pendingFilesView() string {
+	if len(m.pendingFiles) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(Magenta.Render("The following files will be staged on commit:") + "\n")
+	for i, file := range m.pendingFiles {
+		if i == maxPendingFilesShown {
+			sb.WriteString(Cyan.Render(fmt.Sprintf("  ... and %d more", len(m.pendingFiles)-i)) + "\n")
+			break
+		}
+		sb.WriteString(Cyan.Render("  "+file) + "\n")
+	}
+	return sb.String()
+}
+
 func (m *Model) checkGitStatus() tea.Msg {
 	time.Sleep(1000 * time.Millisecond) // Add a small delay
 	if err := m.gitClient.IsInWorkTree(); err != nil {
 		return errMsg{err}
 	}
-	stagedFiles, err := m.gitClient.StagedFiles()
+	changes, err := m.gitClient.StagedChanges()
 	if err != nil {
 		return errMsg{err}
 	}
-	return gitCheckMsg(stagedFiles)
+	return gitCheckMsg(changes)
+}
+
+// stagedPaths are the paths of the staged files, including the original path
+// of a renamed file, the removal of which is staged too.
+func stagedPaths(changes []interfaces.FileChange) []string {
+	var paths []string
+	for _, change := range changes {
+		paths = append(paths, change.Path)
+		if change.Status == "R" {
+			paths = append(paths, change.OldPath)
+		}
+	}
+	return paths
+}
+
+const maxCoAuthorSuggestions = 8
+
+// suggestTrailers looks up the committer, for a sign-off, and the recent
+// authors, as co-authors. Failures are ignored, as these are only suggestions.
+func (m *Model) suggestTrailers() tea.Msg {
+	identity, _ := m.gitClient.Identity()
+	authors, _ := m.gitClient.RecentAuthors(maxCoAuthorSuggestions + 1)
+
+	var coAuthors []string
+	for _, author := range authors {
+		if author != identity && len(coAuthors) < maxCoAuthorSuggestions {
+			coAuthors = append(coAuthors, author)
+		}
+	}
+	return trailerSuggestionsMsg{identity: identity, coAuthors: coAuthors}
 }
 
 func (m *Model) getGitDiff() tea.Msg {
+	if m.squashRange != "" {
+		return m.getSquashDiff()
+	}
+
+	// the annotation and the analysis read the same staged files, so share the reads
+	files := analysis.CachedReader(m.gitClient)
+
+	diff, err := m.gitClient.Diff(m.selectedFiles...)
+	if err != nil {
+		return errMsg{err}
+	}
+	diff = analysis.Annotate(files, "", diff, m.cfg.DiffContext)
+	changes, err := m.gitClient.StagedChanges(m.selectedFiles...)
+	if err != nil {
+		return errMsg{err}
+	}
+	pending, err := m.gitClient.PendingFiles()
+	if err != nil {
+		return errMsg{err}
+	}
+	if m.selectedFiles != nil {
+		pending = slices.DeleteFunc(pending, func(file string) bool {
+			return !slices.Contains(m.selectedFiles, file)
+		})
+	}
+	// the analysis only informs the message, so a failure should not stop the commit
+	breaking, _ := analysis.BreakingChanges(files, changes)
+	return gitDiffMsg{diff: diff, changes: changes, pending: pending, breaking: breaking}
+}
+
+func (m *Model) getSquashDiff() tea.Msg {
+	commits, err := m.squashCommits()
+	if err != nil {
+		return errMsg{err}
+	}
+	if len(commits) == 0 {
+		return errMsg{errors.Newf("no commits found in range %s", m.squashRange)}
+	}
+
+	// the net diff is what is staged, as that is what will be committed, which
+	// need not match the range's own diff when it has merges or its base moved
 	diff, err := m.gitClient.Diff()
 	if err != nil {
 		return errMsg{err}
 	}
-	return gitDiffMsg(diff)
+	diff = analysis.Annotate(m.gitClient, "", diff, m.cfg.DiffContext)
+	changes, err := m.gitClient.StagedChanges()
+	if err != nil {
+		return errMsg{err}
+	}
+	pending, err := m.gitClient.PendingFiles()
+	if err != nil {
+		return errMsg{err}
+	}
+	return gitDiffMsg{diff: diff, changes: changes, pending: pending, commits: commits}
 }
 
-func (m *Model) generateSummary(diff string, userMessage string) tea.Cmd {
+// squashCommits lists the commits being squashed. A single revision is taken
+// to mean the commits since it, up to HEAD, or when HEAD was reset to it with
+// `git reset --soft`, up to where HEAD was before.
+func (m *Model) squashCommits() ([]interfaces.Commit, error) {
+	commits, err := m.gitClient.Commits(m.squashRange)
+	if err != nil || len(commits) > 0 || strings.Contains(m.squashRange, "..") {
+		return commits, err
+	}
+	return m.gitClient.Commits(m.squashRange + "..ORIG_HEAD")
+}
+
+// generateSummary asks the LLM for a commit summary, continuing the
+// conversation of any earlier regenerations. Unless regenerating, a previously
+// cached response for the same prompt is used instead.
+func (m *Model) generateSummary(useCache bool) tea.Cmd {
+	conversation := slices.Clone(m.conversation)
 	return func() tea.Msg {
-		text := fmt.Sprintf(m.systemPrompt, diff)
-		if userMessage != "" {
-			text += "\n\n**IMPORTANT:** " + userMessage
+		template := m.cfg.Prompt
+		if m.squashRange != "" {
+			template = m.cfg.SquashPrompt
+		}
+
+		text, err := prompt.Render(template, prompt.Data{
+			Style:    m.cfg.Style.Instructions(),
+			Language: m.cfg.Language,
+			Format:   m.cfg.Format,
+			Breaking: breakingChanges(m.breaking),
+			Scope:    promptScope(m.cfg, m.changes),
+			Diff:     m.diff,
+			Files:    m.changes,
+			Commits:  m.commits,
+		})
+		if err != nil {
+			return errMsg{err}
+		}
+
+		// only the first answer is cached, as refinements are not repeatable
+		key := cache.Key(m.cfg.LLMProvider, m.llmProvider.Model(), text)
+		if useCache && m.cache != nil && len(conversation) == 0 {
+			if resp, ok := m.cache.Get(key); ok {
+				return llmResultMsg(resp)
+			}
 		}
-		resp, err := m.llmProvider.Call(m.ctx, "", text)
+
+		messages := append([]llmprovider.Message{{Role: llmprovider.RoleUser, Content: text}}, conversation...)
+		resp, err := llmprovider.Generate(m.ctx, m.llmProvider, m.cfg.Structured, "", messages)
 		if err != nil {
 			return errMsg{err}
 		}
+
+		if m.cache != nil && len(conversation) == 0 {
+			_ = m.cache.Put(key, resp) // a failure to cache should not stop the commit
+		}
 		return llmResultMsg(resp)
 	}
 }
 
+// refine adds the current message, as edited, and the user's refinement of it
+// to the conversation.
+func (m *Model) refine(refinement string) {
+	if current := m.LastMessage(); current != "" {
+		m.conversation = append(m.conversation, llmprovider.Message{Role: llmprovider.RoleAssistant, Content: current})
+	}
+	if refinement = strings.TrimSpace(refinement); refinement == "" {
+		refinement = "Write a different commit message for the same changes."
+	}
+	m.conversation = append(m.conversation, llmprovider.Message{Role: llmprovider.RoleUser, Content: refinement})
+}
+
+func breakingChanges(changes []analysis.Change) []string {
+	var list []string
+	for _, change := range changes {
+		list = append(list, change.String())
+	}
+	return list
+}
+
+// formatResponse strips what the model wrapped around its response, and then
+// converts it to the configured message format and style, with the scope that
+// SCOPE_MAP maps the changed paths to, if any. A scope guessed from the paths
+// is only a hint for the model, and so is not forced on the message.
+func formatResponse(cfg *config.Config, response string, paths []string) string {
+	response = llmprovider.Sanitize(response)
+	if cfg.Format == message.FormatPlain {
+		response = message.ToPlain(response)
+	}
+	if scope, mapped := cfg.ScopeMap.Scope(paths); mapped {
+		return cfg.Style.WithScope(response, scope, true)
+	}
+	return cfg.Style.Format(response)
+}
+
+// promptScope is the scope suggested to the model, when one can be inferred.
+func promptScope(cfg *config.Config, changes []interfaces.FileChange) string {
+	scope, _ := cfg.ScopeMap.Scope(changedPaths(changes))
+	return scope
+}
+
+func changedPaths(changes []interfaces.FileChange) []string {
+	paths := make([]string, len(changes))
+	for i, change := range changes {
+		paths[i] = change.Path
+	}
+	return paths
+}
+
 func (m *Model) Err() error {
 	return m.err
 }
@@ -238,3 +700,31 @@ func (m *Model) Action() Action {
 func (m *Model) CommitMessage() string {
 	return m.commitMessage
 }
+
+// KeepMessage reports whether the last message is worth offering again next
+// time: it was edited, restored, or its commit failed. A message that was only
+// generated is not, as it can just as well be generated again.
+func (m *Model) KeepMessage() bool {
+	if m.LastMessage() == "" {
+		return false
+	}
+	if m.restored || m.commitFailed {
+		return true
+	}
+	commitView, ok := m.commitView.(*commitViewModel)
+	return ok && commitView.textarea.Value() != commitView.history.Initial()
+}
+
+// DiffHash is the hash of the staged changes the message was written for.
+func (m *Model) DiffHash() string {
+	return session.HashDiff(m.diff)
+}
+
+// LastMessage is the commit message as last edited, even if the commit was
+// subsequently aborted.
+func (m *Model) LastMessage() string {
+	if commitView, ok := m.commitView.(*commitViewModel); ok {
+		return commitView.textarea.Value()
+	}
+	return m.commitMessage
+}
@@ -2,18 +2,26 @@ package ui
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/cockroachdb/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	"github.com/rm-hull/git-commit-summary/internal/analysis"
+	"github.com/rm-hull/git-commit-summary/internal/config"
 	"github.com/rm-hull/git-commit-summary/internal/interfaces"
+	"github.com/rm-hull/git-commit-summary/internal/keys"
 	llmprovider "github.com/rm-hull/git-commit-summary/internal/llm_provider"
+	"github.com/rm-hull/git-commit-summary/internal/message"
+	"github.com/rm-hull/git-commit-summary/internal/session"
 )
 
 // MockLLMProvider is a mock implementation of llmprovider.Provider
@@ -26,6 +34,11 @@ func (m *MockLLMProvider) Call(ctx context.Context, model string, prompt string)
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockLLMProvider) Chat(ctx context.Context, systemPrompt string, messages []llmprovider.Message) (string, error) {
+	args := m.Called(ctx, systemPrompt, messages)
+	return args.String(0), args.Error(1)
+}
+
 func (m *MockLLMProvider) Model() string {
 	args := m.Called()
 	return args.String(0)
@@ -41,21 +54,101 @@ func (m *MockGitClient) IsInWorkTree() error {
 	return args.Error(0)
 }
 
-func (m *MockGitClient) StagedFiles() ([]string, error) {
+func (m *MockGitClient) GitDir() (string, error) {
 	args := m.Called()
-	return args.Get(0).([]string), args.Error(1)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockGitClient) StagedChanges(paths ...string) ([]interfaces.FileChange, error) {
+	args := m.Called(paths)
+	return args.Get(0).([]interfaces.FileChange), args.Error(1)
+}
+
+func (m *MockGitClient) Diff(paths ...string) (string, error) {
+	args := m.Called(paths)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockGitClient) Commits(revRange string) ([]interfaces.Commit, error) {
+	args := m.Called(revRange)
+	return args.Get(0).([]interfaces.Commit), args.Error(1)
 }
 
-func (m *MockGitClient) Diff() (string, error) {
+func (m *MockGitClient) ChangesBetween(from, to string) ([]interfaces.FileChange, error) {
+	args := m.Called(from, to)
+	return args.Get(0).([]interfaces.FileChange), args.Error(1)
+}
+
+func (m *MockGitClient) DiffBetween(from, to string) (string, error) {
+	args := m.Called(from, to)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockGitClient) PendingFiles() ([]string, error) {
 	args := m.Called()
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockGitClient) UnstagedFiles(paths []string) ([]string, error) {
+	args := m.Called(paths)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockGitClient) Stage(paths []string) error {
+	args := m.Called(paths)
+	return args.Error(0)
+}
+
+func (m *MockGitClient) Unstage(paths []string) error {
+	args := m.Called(paths)
+	return args.Error(0)
+}
+
+func (m *MockGitClient) Patch(paths ...string) (string, error) {
+	args := m.Called(paths)
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockGitClient) FileAt(rev, path string) (string, error) {
+	args := m.Called(rev, path)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockGitClient) ApplyCached(patch string) error {
+	args := m.Called(patch)
+	return args.Error(0)
+}
+
 func (m *MockGitClient) Commit(message string) error {
 	args := m.Called(message)
 	return args.Error(0)
 }
 
+func (m *MockGitClient) IsPushed(hash string) (bool, error) {
+	args := m.Called(hash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockGitClient) Identity() (string, error) {
+	args := m.Called()
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockGitClient) RecentAuthors(limit int) ([]string, error) {
+	args := m.Called(limit)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockGitClient) CheckReword(hashes []string) error {
+	args := m.Called(hashes)
+	return args.Error(0)
+}
+
+func (m *MockGitClient) Reword(messages map[string]string) (string, error) {
+	args := m.Called(messages)
+	return args.String(0), args.Error(1)
+}
+
 func TestModel_Update(t *testing.T) {
 	ctx := context.Background()
 	mockLLM := new(MockLLMProvider)
@@ -66,7 +159,7 @@ func TestModel_Update(t *testing.T) {
 		// Explicitly use the types to avoid "imported and not used" warnings
 		var _ interfaces.GitClient = mockGit
 		var _ llmprovider.Provider = mockLLM
-		return InitialModel(ctx, mockLLM, mockGit, "system prompt", "user message")
+		return InitialModel(ctx, mockLLM, mockGit, &config.Config{Prompt: "system prompt", Keys: keys.Default()}, "user message")
 	}
 
 	t.Run("tea.KeyMsg - CtrlC in showSpinner state", func(t *testing.T) {
@@ -111,18 +204,117 @@ func TestModel_Update(t *testing.T) {
 		m := initialModel()
 		m.state = showSpinner // Ensure initial state is showSpinner
 
-		mockGit.On("Diff").Return("mocked diff content", nil).Once()
+		changes := []interfaces.FileChange{
+			{Status: "M", Path: "file1.go", Added: 1},
+			{Status: "A", Path: "file2.go", Added: 2},
+		}
+		mockGit.On("Diff", []string(nil)).Return("mocked diff content", nil).Once()
+		mockGit.On("StagedChanges", []string(nil)).Return(changes, nil).Once()
+		mockGit.On("PendingFiles").Return([]string{"file3.go"}, nil).Once()
+		mockGit.On("FileAt", "HEAD", "file1.go").Return("package pkg\n\nfunc Old() {}\n", nil).Once()
+		mockGit.On("FileAt", "", "file1.go").Return("package pkg\n", nil).Once()
+		mockGit.On("FileAt", "", "file2.go").Return("package pkg\n", nil).Once()
 
-		updatedModel, cmd := m.Update(gitCheckMsg{"file1.go", "file2.go"})
+		updatedModel, cmd := m.Update(gitCheckMsg(changes))
 
 		assert.Nil(t, updatedModel.(*Model).err)
 		assert.NotNil(t, cmd)
 		msg := cmd()
-		assert.IsType(t, gitDiffMsg(""), msg)
-		assert.Equal(t, gitDiffMsg("mocked diff content"), msg)
+		assert.IsType(t, gitDiffMsg{}, msg)
+		assert.Equal(t, gitDiffMsg{
+			diff:     "mocked diff content",
+			changes:  changes,
+			pending:  []string{"file3.go"},
+			breaking: []analysis.Change{{Package: ".", Name: "Old", Old: "func()"}},
+		}, msg)
+		mockGit.AssertExpectations(t)
+	})
+
+	t.Run("gitCheckMsg - with file selection", func(t *testing.T) {
+		m := initialModel()
+		m.state = showSpinner
+		m.cfg.SelectFiles = true
+
+		updatedModel, _ := m.Update(gitCheckMsg{
+			{Status: "R", OldPath: "old.go", Path: "file1.go"},
+			{Status: "M", Path: "file2.go"},
+		})
+
+		assert.Equal(t, showFileSelect, updatedModel.(*Model).state)
+		assert.Equal(t, []string{"file1.go", "old.go", "file2.go"}, updatedModel.(*Model).stagedFiles)
+		assert.NotNil(t, updatedModel.(*Model).fileSelectView)
+	})
+
+	t.Run("filesSelectedMsg", func(t *testing.T) {
+		m := initialModel()
+		m.state = showFileSelect
+
+		mockGit.On("Diff", []string{"file1.go", "file3.go"}).Return("selected diff", nil).Once()
+		mockGit.On("StagedChanges", []string{"file1.go", "file3.go"}).Return([]interfaces.FileChange{}, nil).Once()
+		mockGit.On("PendingFiles").Return([]string{"file2.go", "file3.go"}, nil).Once()
+
+		updatedModel, cmd := m.Update(filesSelectedMsg{
+			selected: []string{"file1.go", "file3.go"},
+			excluded: []string{"file2.go"},
+		})
+
+		assert.Equal(t, showSpinner, updatedModel.(*Model).state)
+		assert.Equal(t, []string{"file2.go"}, updatedModel.(*Model).excludedFiles)
+		assert.NotNil(t, cmd)
+
+		msg := m.getGitDiff()
+		assert.Equal(t, gitDiffMsg{
+			diff:    "selected diff",
+			changes: []interfaces.FileChange{},
+			pending: []string{"file3.go"},
+		}, msg)
+		mockGit.AssertExpectations(t)
+	})
+
+	t.Run("getGitDiff - squash range", func(t *testing.T) {
+		m := InitialSquashModel(ctx, mockLLM, mockGit, &config.Config{Keys: keys.Default()}, "main..feature", "")
+
+		commits := []interfaces.Commit{
+			{Hash: "bbb", Parents: []string{"aaa"}, Message: "feat: first"},
+			{Hash: "ccc", Parents: []string{"bbb"}, Message: "wip"},
+		}
+		changes := []interfaces.FileChange{{Status: "M", Path: "file.go"}}
+		mockGit.On("Commits", "main..feature").Return(commits, nil).Once()
+		mockGit.On("Diff", []string(nil)).Return("staged diff", nil).Once()
+		mockGit.On("StagedChanges", []string(nil)).Return(changes, nil).Once()
+		mockGit.On("PendingFiles").Return([]string(nil), nil).Once()
+
+		msg := m.getGitDiff()
+
+		assert.Equal(t, gitDiffMsg{diff: "staged diff", changes: changes, commits: commits}, msg)
 		mockGit.AssertExpectations(t)
 	})
 
+	t.Run("getGitDiff - squash after a soft reset", func(t *testing.T) {
+		m := InitialSquashModel(ctx, mockLLM, mockGit, &config.Config{Keys: keys.Default()}, "main", "")
+
+		commits := []interfaces.Commit{{Hash: "bbb", Parents: []string{"aaa"}, Message: "feat: first"}}
+		mockGit.On("Commits", "main").Return([]interfaces.Commit(nil), nil).Once()
+		mockGit.On("Commits", "main..ORIG_HEAD").Return(commits, nil).Once()
+		mockGit.On("Diff", []string(nil)).Return("staged diff", nil).Once()
+		mockGit.On("StagedChanges", []string(nil)).Return([]interfaces.FileChange(nil), nil).Once()
+		mockGit.On("PendingFiles").Return([]string(nil), nil).Once()
+
+		msg := m.getGitDiff()
+
+		assert.Equal(t, gitDiffMsg{diff: "staged diff", commits: commits}, msg)
+		mockGit.AssertExpectations(t)
+	})
+
+	t.Run("gitCheckMsg - squash with nothing staged", func(t *testing.T) {
+		m := InitialSquashModel(ctx, mockLLM, mockGit, &config.Config{Keys: keys.Default()}, "main..feature", "")
+
+		updatedModel, cmd := m.Update(gitCheckMsg{})
+
+		assert.ErrorContains(t, updatedModel.(*Model).err, "git merge --squash")
+		assert.IsType(t, tea.QuitMsg{}, cmd())
+	})
+
 	t.Run("gitDiffMsg", func(t *testing.T) {
 		m := initialModel()
 		m.state = showSpinner // Ensure initial state is showSpinner
@@ -131,14 +323,86 @@ func TestModel_Update(t *testing.T) {
 		// No need to set mockLLM.On("Call") here.
 
 		diffContent := "diff --git a/file.go b/file.go"
-		updatedModel, cmd := m.Update(gitDiffMsg(diffContent))
+		changes := []interfaces.FileChange{{Status: "M", Path: "file.go"}}
+		updatedModel, cmd := m.Update(gitDiffMsg{diff: diffContent, changes: changes})
 
 		assert.Equal(t, diffContent, updatedModel.(*Model).diff)
+		assert.Equal(t, changes, updatedModel.(*Model).changes)
+		assert.Nil(t, updatedModel.(*Model).pendingFiles)
 		assert.Contains(t, updatedModel.(*Model).spinnerMessage, "Generating commit summary (using: test-model)")
 		assert.IsType(t, tea.Batch(nil), cmd)
 		mockLLM.AssertExpectations(t)
 	})
 
+	t.Run("gitDiffMsg - with a saved message", func(t *testing.T) {
+		m := initialModel()
+		m.state = showSpinner
+		m.RestoreMessage("feat: unfinished business", session.HashDiff("diff"))
+		mockLLM.On("Model").Return("test-model").Once()
+
+		updatedModel, _ := m.Update(gitDiffMsg{diff: "diff"})
+
+		assert.Equal(t, showRestorePrompt, updatedModel.(*Model).state)
+		assert.Contains(t, updatedModel.(*Model).View(), "feat: unfinished business")
+	})
+
+	t.Run("gitDiffMsg - with a saved message for other changes", func(t *testing.T) {
+		m := initialModel()
+		m.state = showSpinner
+		m.RestoreMessage("feat: unfinished business", session.HashDiff("other diff"))
+		mockLLM.On("Model").Return("test-model").Once()
+
+		updatedModel, cmd := m.Update(gitDiffMsg{diff: "diff"})
+
+		assert.Equal(t, showSpinner, updatedModel.(*Model).state)
+		assert.IsType(t, tea.Batch(nil), cmd)
+	})
+
+	t.Run("choiceMsg - restore saved message", func(t *testing.T) {
+		m := initialModel()
+		m.state = showRestorePrompt
+		m.RestoreMessage("feat: unfinished business", "")
+
+		updatedModel, cmd := m.Update(choiceMsg("r"))
+
+		assert.Equal(t, showCommitView, updatedModel.(*Model).state)
+		assert.Equal(t, "feat: unfinished business", updatedModel.(*Model).LastMessage())
+		assert.True(t, updatedModel.(*Model).KeepMessage(), "a restored message is kept again")
+		assert.IsType(t, textarea.Blink(), cmd())
+	})
+
+	t.Run("KeepMessage - only an edited message", func(t *testing.T) {
+		m := initialModel()
+		assert.False(t, m.KeepMessage(), "no message")
+
+		updatedModel, _ := m.Update(llmResultMsg("feat: generated"))
+		m = updatedModel.(*Model)
+		assert.False(t, m.KeepMessage(), "the message was only generated")
+
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("!")})
+		assert.True(t, m.KeepMessage(), "the message was edited")
+	})
+
+	t.Run("KeepMessage - after a failed commit", func(t *testing.T) {
+		m := initialModel()
+		updatedModel, _ := m.Update(llmResultMsg("feat: generated"))
+		m = updatedModel.(*Model)
+
+		m.Update(commitResultMsg{err: errors.New("hook failed")})
+		assert.True(t, m.KeepMessage())
+	})
+
+	t.Run("choiceMsg - generate a new message", func(t *testing.T) {
+		m := initialModel()
+		m.state = showRestorePrompt
+		m.RestoreMessage("feat: unfinished business", "")
+
+		updatedModel, cmd := m.Update(choiceMsg("g"))
+
+		assert.Equal(t, showSpinner, updatedModel.(*Model).state)
+		assert.IsType(t, tea.Batch(nil), cmd)
+	})
+
 	t.Run("llmResultMsg - with user message", func(t *testing.T) {
 		m := initialModel()
 		m.state = showSpinner // Ensure initial state is showSpinner
@@ -171,6 +435,61 @@ func TestModel_Update(t *testing.T) {
 		assert.IsType(t, textarea.Blink(), cmd())
 	})
 
+	t.Run("llmResultMsg - breaking changes", func(t *testing.T) {
+		m := initialModel()
+		m.state = showSpinner
+		m.userMessage = ""
+		m.breaking = []analysis.Change{{Package: "pkg", Name: "Old", Old: "func()"}}
+
+		updatedModel, _ := m.Update(llmResultMsg("refactor(pkg): tidy up"))
+
+		commitView := updatedModel.(*Model).commitView.(*commitViewModel)
+		assert.Equal(t, "refactor(pkg)!: tidy up\n\nBREAKING-CHANGE: Old was removed", commitView.textarea.Value())
+	})
+
+	t.Run("llmResultMsg - scope", func(t *testing.T) {
+		m := initialModel()
+		m.state = showSpinner
+		m.userMessage = ""
+		m.cfg.ScopeMap = message.ScopeMap{{Pattern: "web/**", Scope: "web"}}
+
+		m.changes = []interfaces.FileChange{{Status: "M", Path: "web/index.ts"}}
+		updatedModel, _ := m.Update(llmResultMsg("fix(frontend): handle resize"))
+		commitView := updatedModel.(*Model).commitView.(*commitViewModel)
+		assert.Equal(t, "fix(web): handle resize", commitView.textarea.Value())
+
+		// a scope guessed from the paths is only suggested to the model
+		m.changes = []interfaces.FileChange{{Status: "M", Path: "internal/ui/model.go"}}
+		assert.Equal(t, "ui", promptScope(m.cfg, m.changes))
+		updatedModel, _ = m.Update(llmResultMsg("fix: handle resize"))
+		commitView = updatedModel.(*Model).commitView.(*commitViewModel)
+		assert.Equal(t, "fix: handle resize", commitView.textarea.Value())
+	})
+
+	t.Run("llmResultMsg - sanitized", func(t *testing.T) {
+		m := initialModel()
+		m.state = showSpinner
+		m.userMessage = ""
+
+		updatedModel, _ := m.Update(llmResultMsg("<think>\nA fix.\n</think>\nHere is the commit message:\n\n```\nfix: handle empty diffs\n```"))
+
+		commitView := updatedModel.(*Model).commitView.(*commitViewModel)
+		assert.Equal(t, "fix: handle empty diffs", commitView.textarea.Value())
+	})
+
+	t.Run("llmResultMsg - plain format", func(t *testing.T) {
+		m := initialModel()
+		m.cfg.Format = message.FormatPlain
+		m.state = showSpinner
+		m.userMessage = ""
+
+		updatedModel, _ := m.Update(llmResultMsg("feat: add **bold** things\n\n## Why\n\nSee [docs](https://example.com)."))
+
+		commitView := updatedModel.(*Model).commitView.(*commitViewModel)
+		assert.Equal(t, "feat: add bold things\n\nWhy\n\nSee docs (https://example.com).", commitView.textarea.Value())
+		assert.True(t, commitView.plain)
+	})
+
 	t.Run("commitMsg", func(t *testing.T) {
 		m := initialModel()
 		m.state = showCommitView // Ensure state is showCommitView
@@ -178,12 +497,164 @@ func TestModel_Update(t *testing.T) {
 		commitContent := "feat: new feature"
 		updatedModel, cmd := m.Update(commitMsg(commitContent))
 
-		assert.Equal(t, Commit, updatedModel.(*Model).action)
+		assert.Equal(t, None, updatedModel.(*Model).action)
 		assert.Equal(t, commitContent, updatedModel.(*Model).commitMessage)
 		assert.NotNil(t, cmd)
+	})
+
+	t.Run("commitCommand - stages and commits", func(t *testing.T) {
+		git := new(MockGitClient)
+		git.On("Unstage", []string{"excluded.go"}).Return(nil).Once()
+		git.On("Stage", []string{"pending.go"}).Return(nil).Once()
+		git.On("Commit", "feat: new feature").Return(nil).Once()
+
+		git.On("UnstagedFiles", []string{"file.go", "pending.go"}).Return([]string(nil), nil).Once()
+
+		cmd := &commitCommand{
+			gitClient: git,
+			message:   "feat: new feature",
+			stage:     []string{"pending.go"},
+			unstage:   []string{"excluded.go"},
+			committed: []string{"file.go", "pending.go"},
+		}
+		assert.NoError(t, cmd.Run())
+		git.AssertExpectations(t)
+	})
+
+	t.Run("commitCommand - tells which files a failed commit's hooks changed", func(t *testing.T) {
+		git := new(MockGitClient)
+		git.On("Unstage", []string(nil)).Return(nil).Once()
+		git.On("Stage", []string(nil)).Return(nil).Once()
+		git.On("UnstagedFiles", []string{"a.go", "b.go", "c.go"}).Return([]string{"b.go"}, nil).Once()
+		git.On("Commit", "feat: new feature").Return(errors.New("hook failed")).Once()
+		git.On("UnstagedFiles", []string{"a.go", "b.go", "c.go"}).Return([]string{"a.go", "b.go"}, nil).Once()
+
+		cmd := &commitCommand{
+			gitClient: git,
+			message:   "feat: new feature",
+			committed: []string{"a.go", "b.go", "c.go"},
+		}
+		assert.EqualError(t, cmd.Run(), "hook failed")
+		assert.Equal(t, []string{"a.go"}, cmd.hookChanged)
+		assert.Equal(t, []string{"b.go"}, cmd.partlyStaged)
+		git.AssertExpectations(t)
+	})
+
+	t.Run("commitResultMsg - success", func(t *testing.T) {
+		m := initialModel()
+		m.state = showCommitView
+
+		updatedModel, cmd := m.Update(commitResultMsg{})
+
+		assert.Equal(t, Commit, updatedModel.(*Model).action)
 		assert.IsType(t, tea.QuitMsg{}, cmd())
 	})
 
+	t.Run("commitResultMsg - pre-commit hook failure", func(t *testing.T) {
+		m := initialModel()
+		m.state = showCommitView
+
+		updatedModel, _ := m.Update(commitResultMsg{
+			err: &interfaces.CommitError{
+				Output: "gofmt.....Failed\n- files were modified by this hook\n",
+				Err:    errors.New("git commit failed"),
+			},
+			hookChanged:  []string{"file1.go"},
+			partlyStaged: []string{"file2.go"},
+		})
+
+		assert.Equal(t, showCommitFailure, updatedModel.(*Model).state)
+		assert.Equal(t, None, updatedModel.(*Model).action)
+		assert.Equal(t, "gofmt.....Failed\n- files were modified by this hook", updatedModel.(*Model).failureOutput)
+		view := updatedModel.View()
+		assert.Contains(t, view, "re-stage & retry")
+		assert.Contains(t, view, "hooks' changes to: file1.go")
+		assert.Contains(t, view, "not re-staged: file2.go")
+	})
+
+	t.Run("choiceMsg - re-stage and retry after a failed commit", func(t *testing.T) {
+		m := initialModel()
+		m.state = showCommitFailure
+		m.stagedFiles = []string{"file1.go", "file2.go"}
+		m.hookChanged = []string{"file2.go"}
+
+		mockGit.On("Stage", []string{"file2.go"}).Return(nil).Once()
+
+		updatedModel, cmd := m.Update(choiceMsg("s"))
+		assert.Equal(t, restagedMsg{}, cmd())
+		mockGit.AssertExpectations(t)
+
+		_, cmd = updatedModel.Update(restagedMsg{})
+		assert.NotNil(t, cmd)
+	})
+
+	t.Run("choiceMsg - re-stage and regenerate after a failed commit", func(t *testing.T) {
+		m := initialModel()
+		m.state = showCommitFailure
+		m.selectedFiles = []string{"file1.go"}
+		m.hookChanged = []string{"file1.go"}
+		mockLLM.On("Model").Return("test-model").Once()
+
+		mockGit.On("Stage", []string{"file1.go"}).Return(nil).Once()
+
+		updatedModel, cmd := m.Update(choiceMsg("r"))
+		assert.Equal(t, restagedMsg{}, cmd())
+		mockGit.AssertExpectations(t)
+
+		updatedModel, _ = updatedModel.Update(restagedMsg{})
+		assert.Equal(t, showSpinner, updatedModel.(*Model).state)
+		assert.True(t, updatedModel.(*Model).regenerate)
+		mockLLM.AssertExpectations(t)
+	})
+
+	t.Run("choiceMsg - retry after a failed commit leaves the hooks' changes unstaged", func(t *testing.T) {
+		m := initialModel()
+		m.state = showCommitFailure
+		m.commitMessage = "feat: new feature"
+		m.pendingFiles = []string{"a.go", "b.go"}
+		m.hookChanged = []string{"a.go"}
+
+		_, cmd := m.Update(choiceMsg("c"))
+		assert.NotNil(t, cmd)
+
+		git := new(MockGitClient)
+		git.On("Unstage", []string(nil)).Return(nil).Once()
+		git.On("Stage", []string{"b.go"}).Return(nil).Once()
+		git.On("UnstagedFiles", []string{"a.go", "b.go"}).Return([]string{"a.go"}, nil).Once()
+		git.On("Commit", "feat: new feature").Return(nil).Once()
+
+		m.gitClient = git
+		assert.NoError(t, m.commitCommand().Run())
+		git.AssertExpectations(t)
+	})
+
+	t.Run("choiceMsg - edit after a failed commit", func(t *testing.T) {
+		m := initialModel()
+		m.state = showCommitFailure
+
+		mockCommitView := new(mockTeaModel)
+		mockCommitView.On("Init").Return((tea.Cmd)(nil)).Once()
+		m.commitView = mockCommitView
+
+		updatedModel, _ := m.Update(choiceMsg("e"))
+
+		assert.Equal(t, showCommitView, updatedModel.(*Model).state)
+		mockCommitView.AssertExpectations(t)
+	})
+
+	t.Run("choiceMsg - edit brings back the help", func(t *testing.T) {
+		m := initialModel()
+		m.showCommitView("feat: add x")
+		commitView := m.commitView.(*commitViewModel)
+		commitView.Update(tea.KeyMsg{Type: tea.KeyCtrlX})
+		assert.False(t, commitView.helpText)
+
+		m.state = showCommitFailure
+		m.Update(choiceMsg("e"))
+		assert.True(t, commitView.helpText)
+		assert.Contains(t, m.View(), "commit")
+	})
+
 	t.Run("regenerateMsg", func(t *testing.T) {
 		m := initialModel()
 		m.state = showCommitView // Ensure state is showCommitView
@@ -228,6 +699,208 @@ func TestModel_Update(t *testing.T) {
 		mockCommitView.AssertExpectations(t)
 	})
 
+	t.Run("generateSummary - uses cache unless regenerating", func(t *testing.T) {
+		cfg := &config.Config{
+			LLMProvider: "test",
+			Prompt:      "summarize: {{.Diff}}",
+			Cache:       config.CacheConfig{Dir: t.TempDir(), TTL: time.Hour, MaxSize: 1024},
+			Keys:        keys.Default(),
+		}
+		llm := new(MockLLMProvider)
+		m := InitialModel(ctx, llm, mockGit, cfg, "")
+		m.diff = "some diff"
+		prompt := []llmprovider.Message{{Role: llmprovider.RoleUser, Content: "summarize: some diff"}}
+
+		llm.On("Model").Return("test-model")
+		llm.On("Chat", ctx, "", prompt).Return("feat: first", nil).Once()
+		assert.Equal(t, llmResultMsg("feat: first"), m.generateSummary(true)())
+		assert.Equal(t, llmResultMsg("feat: first"), m.generateSummary(true)())
+
+		llm.On("Chat", ctx, "", prompt).Return("feat: second", nil).Once()
+		assert.Equal(t, llmResultMsg("feat: second"), m.generateSummary(false)())
+		assert.Equal(t, llmResultMsg("feat: second"), m.generateSummary(true)())
+		llm.AssertExpectations(t)
+	})
+
+	t.Run("generateSummary - continues the conversation when refining", func(t *testing.T) {
+		cfg := &config.Config{LLMProvider: "test", Prompt: "summarize: {{.Diff}}", Keys: keys.Default()}
+		llm := new(MockLLMProvider)
+		m := InitialModel(ctx, llm, mockGit, cfg, "")
+		m.diff = "some diff"
+		m.commitView, _ = initialCommitViewModel("feat: add a long subject\n\nand a body", false, keys.Default())
+
+		llm.On("Model").Return("test-model")
+		m.Update(userResponseMsg("shorter"))
+		m.Update(userResponseMsg(""))
+
+		llm.On("Chat", ctx, "", []llmprovider.Message{
+			{Role: llmprovider.RoleUser, Content: "summarize: some diff"},
+			{Role: llmprovider.RoleAssistant, Content: "feat: add a long subject\n\nand a body"},
+			{Role: llmprovider.RoleUser, Content: "shorter"},
+			{Role: llmprovider.RoleAssistant, Content: "feat: add a long subject\n\nand a body"},
+			{Role: llmprovider.RoleUser, Content: "Write a different commit message for the same changes."},
+		}).Return("feat: add subject", nil).Once()
+		assert.Equal(t, llmResultMsg("feat: add subject"), m.generateSummary(false)())
+		llm.AssertExpectations(t)
+
+		history := conversationView(m.conversation)
+		assert.Contains(t, history, "feat: add a long subject")
+		assert.NotContains(t, history, "and a body")
+		assert.Contains(t, history, "❯ shorter")
+	})
+
+	t.Run("showCommitView - keeps the earlier generations", func(t *testing.T) {
+		m := InitialModel(ctx, mockLLM, mockGit, &config.Config{Keys: keys.Default()}, "")
+		m.showCommitView("feat: first")
+		commitView := m.commitView.(*commitViewModel)
+		commitView.textarea.SetValue("feat: first, edited")
+		commitView.history.Add("feat: first, edited")
+
+		m.showCommitView("feat: second")
+		commitView = m.commitView.(*commitViewModel)
+		assert.Equal(t, 2, m.generations.Len())
+		assert.Contains(t, commitView.View(), "version 2 of 2")
+
+		commitView.Update(tea.KeyMsg{Type: tea.KeyPgUp})
+		assert.Equal(t, "feat: first, edited", m.LastMessage())
+		commitView.Update(tea.KeyMsg{Type: tea.KeyPgUp})
+		assert.Equal(t, "feat: first, edited", m.LastMessage())
+		commitView.Update(tea.KeyMsg{Type: tea.KeyCtrlZ})
+		assert.Equal(t, "feat: first", m.LastMessage())
+
+		commitView.Update(tea.KeyMsg{Type: tea.KeyPgDown})
+		assert.Equal(t, "feat: second", m.LastMessage())
+		commitView.Update(tea.KeyMsg{Type: tea.KeyCtrlG})
+		assert.True(t, commitView.diffing)
+		assert.Contains(t, commitView.View(), "changes since version 1")
+		commitView.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		assert.False(t, commitView.diffing)
+	})
+
+	t.Run("showCommitView - flags a long subject, without shortening it", func(t *testing.T) {
+		cfg := &config.Config{Layout: message.Layout{SubjectWidth: 20}, Keys: keys.Default()}
+		m := InitialModel(ctx, mockLLM, mockGit, cfg, "")
+		m.showCommitView(cfg.Layout.Apply("feat: add a rather long subject line\n\nBody."))
+		commitView := m.commitView.(*commitViewModel)
+
+		assert.Equal(t, "feat: add a rather long subject line\n\nBody.", m.LastMessage())
+		assert.Contains(t, commitView.View(), "The subject is 36 characters long, over the 20 it should be.")
+
+		commitView.textarea.SetValue("feat: add a subject")
+		assert.NotContains(t, commitView.View(), "The subject is")
+	})
+
+	t.Run("showCommitView - opens the diff at the file on the cursor's line", func(t *testing.T) {
+		m := InitialModel(ctx, mockLLM, mockGit, &config.Config{Keys: keys.Default()}, "")
+		m.diff = stagedDiff
+		m.showCommitView("docs: update\n\n* Add docs/new.md")
+		commitView := m.commitView.(*commitViewModel)
+
+		commitView.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+		assert.True(t, commitView.viewingStagedDiff)
+		assert.Equal(t, 2, commitView.stagedDiff.file)
+		assert.Contains(t, commitView.View(), "docs/new.md (3 of 3)")
+
+		commitView.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		assert.False(t, commitView.viewingStagedDiff)
+		assert.Equal(t, "docs: update\n\n* Add docs/new.md", m.LastMessage())
+	})
+
+	t.Run("WindowSizeMsg - fits the commit view to the terminal", func(t *testing.T) {
+		m := InitialModel(ctx, mockLLM, mockGit, &config.Config{Keys: keys.Default()}, "")
+		m.diff = stagedDiff
+		long := "feat: a long message" + strings.Repeat("\n\n* a line", 20)
+		m.showCommitView(long)
+		commitView := m.commitView.(*commitViewModel)
+		assert.Equal(t, defaultMaxHeight, commitView.textarea.Height())
+
+		m.Update(tea.WindowSizeMsg{Width: 200, Height: 60})
+		assert.Equal(t, messageWidth+2, commitView.textarea.Width())
+		assert.Equal(t, strings.Count(long, "\n")+1, commitView.textarea.Height())
+		assert.False(t, commitView.stackedPane)
+		assert.Equal(t, 200-(messageWidth+6)-4, commitView.stagedDiff.viewport.Width)
+
+		m.Update(tea.WindowSizeMsg{Width: 60, Height: 20})
+		assert.Equal(t, 56, commitView.textarea.Width())
+		assert.Equal(t, 16, commitView.textarea.Height())
+		assert.True(t, commitView.stackedPane)
+		for _, line := range strings.Split(commitView.View(), "\n") {
+			assert.LessOrEqual(t, lipgloss.Width(line), 60)
+		}
+
+		m.Update(regenerateMsg{})
+		assert.Equal(t, 57, m.promptView.(*promptViewModel).textinput.Width)
+	})
+
+	t.Run("showCommitView - uses the configured key bindings", func(t *testing.T) {
+		keyMap, err := keys.Parse("commit=ctrl+s")
+		assert.NoError(t, err)
+		m := InitialModel(ctx, mockLLM, mockGit, &config.Config{Keys: keyMap}, "")
+		m.showCommitView("feat: rebind")
+		commitView := m.commitView.(*commitViewModel)
+
+		_, cmd := commitView.Update(tea.KeyMsg{Type: tea.KeyCtrlX})
+		assert.Nil(t, cmd)
+		_, cmd = commitView.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+		assert.Equal(t, commitMsg("feat: rebind"), cmd())
+	})
+
+	t.Run("showCommitView - shows the full help", func(t *testing.T) {
+		m := InitialModel(ctx, mockLLM, mockGit, &config.Config{Keys: keys.Default()}, "")
+		m.showCommitView("feat: help")
+		commitView := m.commitView.(*commitViewModel)
+		assert.Contains(t, commitView.View(), "F1/?:help")
+
+		// typed into the message while editing it
+		commitView.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'?'}})
+		assert.False(t, commitView.showingHelp)
+		assert.Equal(t, "feat: help?", m.LastMessage())
+
+		commitView.Update(tea.KeyMsg{Type: tea.KeyF1})
+		assert.True(t, commitView.showingHelp)
+		assert.Contains(t, commitView.View(), "CTRL+Y")
+		assert.Contains(t, commitView.View(), "redo")
+
+		commitView.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		assert.False(t, commitView.showingHelp)
+		assert.Equal(t, "feat: help?", m.LastMessage())
+	})
+
+	t.Run("suggestTrailers", func(t *testing.T) {
+		git := new(MockGitClient)
+		git.On("Identity").Return("Me <me@example.com>", nil).Once()
+		git.On("RecentAuthors", 9).Return([]string{"Me <me@example.com>", "Jo <jo@example.com>"}, nil).Once()
+		m := InitialModel(ctx, mockLLM, git, &config.Config{Keys: keys.Default()}, "")
+
+		assert.Equal(t, trailerSuggestionsMsg{
+			identity:  "Me <me@example.com>",
+			coAuthors: []string{"Jo <jo@example.com>"},
+		}, m.suggestTrailers())
+		git.AssertExpectations(t)
+	})
+
+	t.Run("trailerSuggestionsMsg - after the commit view is shown", func(t *testing.T) {
+		m := initialModel()
+		m.cfg.SignOff = true
+		m.showCommitView("feat: add trailers")
+
+		m.Update(trailerSuggestionsMsg{identity: "Me <me@example.com>"})
+
+		assert.Contains(t, updatedCommitMessage(m), "Signed-off-by: Me <me@example.com>")
+	})
+
+	t.Run("llmResultMsg - keeps trailers when regenerating", func(t *testing.T) {
+		m := initialModel()
+		m.userMessage = ""
+		m.Update(trailerSuggestionsMsg{identity: "Me <me@example.com>"})
+		m.showCommitView("feat: first attempt")
+		m.commitView.(*commitViewModel).trailers.items[0].selected = true
+
+		m.Update(llmResultMsg("feat: second attempt"))
+
+		assert.Equal(t, "feat: second attempt\n\nSigned-off-by: Me <me@example.com>", updatedCommitMessage(m))
+	})
+
 	t.Run("errMsg", func(t *testing.T) {
 		m := initialModel()
 		m.state = showSpinner // Ensure state is showSpinner
@@ -333,3 +1006,7 @@ func (m *mockTeaModel) View() string {
 	}
 	return ""
 }
+
+func updatedCommitMessage(m *Model) string {
+	return m.commitView.(*commitViewModel).fullMessage()
+}
@@ -2,14 +2,18 @@ package ui
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	llmprovider "github.com/rm-hull/git-commit-summary/internal/llm_provider"
 )
 
 type promptViewModel struct {
 	message   string
 	textinput textinput.Model
+	width     int
 }
 
 func initialPromptViewModel(message, placeholder string) *promptViewModel {
@@ -26,6 +30,12 @@ func initialPromptViewModel(message, placeholder string) *promptViewModel {
 	}
 }
 
+// setWidth fits the prompt to the width of the terminal.
+func (m *promptViewModel) setWidth(width int) {
+	m.width = width
+	m.textinput.Width = max(min(80, width-lipgloss.Width(m.textinput.Prompt)-1), minWidth)
+}
+
 func (m promptViewModel) Init() tea.Cmd {
 	return textinput.Blink
 }
@@ -56,7 +66,27 @@ func (m promptViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m promptViewModel) View() string {
 	return fmt.Sprintf(
 		"%s\n%s\n",
-		m.message,
+		fitWidth(m.message, m.width),
 		m.textinput.View(),
 	)
 }
+
+// conversationView lists the earlier answers, by their subject lines, and the
+// refinements asked for after each, above the prompt for the next one.
+func conversationView(conversation []llmprovider.Message) string {
+	if len(conversation) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(Magenta.Render("Previous refinements:") + "\n\n")
+	for _, msg := range conversation {
+		if msg.Role == llmprovider.RoleAssistant {
+			subject, _, _ := strings.Cut(msg.Content, "\n")
+			sb.WriteString(Grey.Render("  "+subject) + "\n")
+		} else {
+			sb.WriteString(Cyan.Render("❯ "+msg.Content) + "\n")
+		}
+	}
+	return sb.String() + "\n"
+}
@@ -0,0 +1,375 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rm-hull/git-commit-summary/internal/analysis"
+	"github.com/rm-hull/git-commit-summary/internal/config"
+	"github.com/rm-hull/git-commit-summary/internal/interfaces"
+	"github.com/rm-hull/git-commit-summary/internal/keys"
+	llmprovider "github.com/rm-hull/git-commit-summary/internal/llm_provider"
+	"github.com/rm-hull/git-commit-summary/internal/prompt"
+)
+
+type rewordState int
+
+const (
+	showRewordSpinner rewordState = iota
+	showRewordReview
+	showRewordEditor
+	showRewordConfirm
+)
+
+type rewordDecision int
+
+const (
+	undecided rewordDecision = iota
+	accepted
+	skipped
+)
+
+// rewordKeyMap holds the key bindings of the review view, where aborting uses
+// the key configured for the commit message editor.
+type rewordKeyMap struct {
+	Accept   key.Binding
+	Edit     key.Binding
+	Skip     key.Binding
+	Previous key.Binding
+	Next     key.Binding
+	Abort    key.Binding
+}
+
+func newRewordKeyMap(keyMap keys.Map) rewordKeyMap {
+	return rewordKeyMap{
+		Accept:   key.NewBinding(key.WithKeys("enter", "a"), key.WithHelp("enter", "accept")),
+		Edit:     key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "edit")),
+		Skip:     key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "skip")),
+		Previous: key.NewBinding(key.WithKeys("left", "h"), key.WithHelp("←", "previous")),
+		Next:     key.NewBinding(key.WithKeys("right", "l"), key.WithHelp("→", "next")),
+		Abort:    key.NewBinding(key.WithKeys(keyMap.Abort.Keys()...), key.WithHelp(keyMap.Abort.Help().Key, "abort")),
+	}
+}
+
+type rewordProposalMsg struct {
+	index   int
+	message string
+}
+
+// RewordModel proposes a new message for each of the given commits, generated
+// from the commit's own diff, and lets the user accept, edit or skip each one.
+type RewordModel struct {
+	ctx            context.Context
+	state          rewordState
+	llmProvider    llmprovider.Provider
+	gitClient      interfaces.GitClient
+	cfg            *config.Config
+	spinner        spinner.Model
+	spinnerMessage string
+	commits        []interfaces.Commit
+	proposals      []string
+	decisions      []rewordDecision
+	cursor         int
+	editView       tea.Model
+	choiceView     tea.Model
+	keys           rewordKeyMap
+	width          int
+	height         int
+	action         Action
+	err            error
+}
+
+func InitialRewordModel(
+	ctx context.Context,
+	llmProvider llmprovider.Provider,
+	gitClient interfaces.GitClient,
+	cfg *config.Config,
+	commits []interfaces.Commit,
+) *RewordModel {
+	return &RewordModel{
+		ctx:         ctx,
+		state:       showRewordSpinner,
+		llmProvider: llmProvider,
+		gitClient:   gitClient,
+		cfg:         cfg,
+		spinner:     spinner.New(spinner.WithSpinner(spinner.MiniDot)),
+		commits:     commits,
+		proposals:   make([]string, len(commits)),
+		decisions:   make([]rewordDecision, len(commits)),
+		keys:        newRewordKeyMap(cfg.Keys),
+		action:      None,
+	}
+}
+
+func (m *RewordModel) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, m.generate(0))
+}
+
+func (m *RewordModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		resize(m.editView, m.width, m.height-1) // less the heading
+
+	case tea.KeyMsg:
+		switch m.state {
+		case showRewordSpinner:
+			if msg.Type == tea.KeyCtrlC {
+				m.action = Abort
+				return m, tea.Quit
+			}
+
+		case showRewordReview:
+			switch {
+			case key.Matches(msg, m.keys.Accept):
+				return m.decide(accepted)
+			case key.Matches(msg, m.keys.Skip):
+				return m.decide(skipped)
+			case key.Matches(msg, m.keys.Edit):
+				m.state = showRewordEditor
+				m.editView, m.err = newCommitView(m.cfg, m.proposals[m.cursor])
+				if m.err != nil {
+					return m, tea.Quit
+				}
+				resize(m.editView, m.width, m.height-1)
+				return m, m.editView.Init()
+			case key.Matches(msg, m.keys.Previous):
+				if m.cursor > 0 {
+					m.cursor--
+				}
+			case key.Matches(msg, m.keys.Next):
+				if m.cursor < len(m.commits)-1 {
+					m.cursor++
+				}
+			case msg.Type == tea.KeyCtrlC, key.Matches(msg, m.keys.Abort):
+				m.action = Abort
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+
+	case rewordProposalMsg:
+		m.proposals[msg.index] = msg.message
+		if next := msg.index + 1; next < len(m.commits) {
+			return m, m.generate(next)
+		}
+		m.state = showRewordReview
+		return m, nil
+
+	case commitMsg:
+		// the edited message for the current commit has been accepted
+		m.proposals[m.cursor] = strings.TrimSpace(string(msg))
+		m.state = showRewordReview
+		return m.decide(accepted)
+
+	case choiceMsg:
+		switch msg {
+		case "y":
+			m.action = Commit
+			return m, tea.Quit
+		case "r":
+			m.state = showRewordReview
+			return m, nil
+		default:
+			m.action = Abort
+			return m, tea.Quit
+		}
+
+	case abortMsg, regenerateMsg:
+		if m.state == showRewordConfirm {
+			m.action = Abort
+			return m, tea.Quit
+		}
+		// editing a message was cancelled, regeneration is not supported here
+		m.state = showRewordReview
+		return m, nil
+
+	case errMsg:
+		m.err = msg.err
+		return m, tea.Quit
+	}
+
+	var cmd tea.Cmd
+	switch m.state {
+	case showRewordSpinner:
+		m.spinner, cmd = m.spinner.Update(msg)
+	case showRewordEditor:
+		m.editView, cmd = m.editView.Update(msg)
+	case showRewordConfirm:
+		m.choiceView, cmd = m.choiceView.Update(msg)
+	}
+	return m, cmd
+}
+
+// decide records the decision for the current commit, and moves on to the
+// next undecided commit. Once every commit has been decided, the user is
+// asked to confirm before the history is rewritten.
+func (m *RewordModel) decide(decision rewordDecision) (tea.Model, tea.Cmd) {
+	m.decisions[m.cursor] = decision
+
+	for i := range m.commits {
+		next := (m.cursor + 1 + i) % len(m.commits)
+		if m.decisions[next] == undecided {
+			m.cursor = next
+			return m, nil
+		}
+	}
+
+	messages := m.Messages()
+	if len(messages) == 0 {
+		// every commit was skipped, so there is nothing to rewrite
+		m.action = Commit
+		return m, tea.Quit
+	}
+
+	m.state = showRewordConfirm
+	m.choiceView = initialChoiceViewModel(m.confirmMessage(messages),
+		choice{key: "y", label: "rewrite"},
+		choice{key: "r", label: "review"},
+		choice{key: "esc", label: "abort"},
+	)
+	return m, m.choiceView.Init()
+}
+
+// confirmMessage lists the new subject of each commit to be reworded.
+func (m *RewordModel) confirmMessage(messages map[string]string) string {
+	var sb strings.Builder
+	sb.WriteString(Magenta.Render(fmt.Sprintf("Rewrite the history of the current branch, rewording %d of %d commits?", len(messages), len(m.commits))) + "\n\n")
+	for _, commit := range m.commits {
+		if message, ok := messages[commit.Hash]; ok {
+			subject, _, _ := strings.Cut(message, "\n")
+			sb.WriteString(Cyan.Render(shortHash(commit.Hash)) + " " + subject + "\n")
+		}
+	}
+	return sb.String()
+}
+
+func (m *RewordModel) View() string {
+	switch m.state {
+	case showRewordSpinner:
+		return m.spinner.View() + " " + m.spinnerMessage
+	case showRewordReview:
+		return m.reviewView()
+	case showRewordEditor:
+		return Magenta.Render(fmt.Sprintf("Editing message for %s:", shortHash(m.commits[m.cursor].Hash))) +
+			"\n" + m.editView.View()
+	case showRewordConfirm:
+		return m.choiceView.View()
+	default:
+		return ""
+	}
+}
+
+func (m *RewordModel) reviewView() string {
+	commit := m.commits[m.cursor]
+
+	status := ""
+	switch m.decisions[m.cursor] {
+	case accepted:
+		status = Cyan.Render(" (accepted)")
+	case skipped:
+		status = Cyan.Render(" (skipped)")
+	}
+
+	width := boxWidth(m.width)
+	box := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(activeTheme.Cyan).
+		Padding(0, 1).
+		Width(width)
+
+	current := box.Render(BoldBlue.Render("Current") + "\n\n" + commit.Message)
+	proposed := box.Render(BoldBlue.Render("Proposed") + "\n\n" + m.proposals[m.cursor])
+	messages := lipgloss.JoinHorizontal(lipgloss.Top, current, proposed)
+	if m.width > 0 && m.width < 2*(width+2) {
+		// one above the other, when there is no room for them side by side
+		messages = lipgloss.JoinVertical(lipgloss.Left, current, proposed)
+	}
+
+	return Magenta.Render(fmt.Sprintf("Commit %d of %d: %s", m.cursor+1, len(m.commits), shortHash(commit.Hash))) + status + "\n" +
+		messages + "\n" +
+		fitWidth(helpLine(
+			helpEntry(m.keys.Accept, true),
+			helpEntry(m.keys.Edit, true),
+			helpEntry(m.keys.Skip, true),
+			helpKey(m.keys.Previous)+"/"+helpKey(m.keys.Next)+":prev/next",
+			helpEntry(m.keys.Abort, true)), m.width)
+}
+
+func (m *RewordModel) generate(index int) tea.Cmd {
+	m.spinnerMessage = fmt.Sprintf("%s%s%s",
+		Blue.Render(fmt.Sprintf("Generating commit message %d of %d (using: ", index+1, len(m.commits))),
+		BoldBlue.Render(m.llmProvider.Model()),
+		Blue.Render(")"),
+	)
+
+	commit := m.commits[index]
+	return func() tea.Msg {
+		var parent string
+		if len(commit.Parents) > 0 {
+			parent = commit.Parents[0]
+		}
+
+		diff, err := m.gitClient.DiffBetween(parent, commit.Hash)
+		if err != nil {
+			return errMsg{err}
+		}
+		diff = analysis.Annotate(m.gitClient, commit.Hash, diff, m.cfg.DiffContext)
+		changes, err := m.gitClient.ChangesBetween(parent, commit.Hash)
+		if err != nil {
+			return errMsg{err}
+		}
+
+		text, err := prompt.Render(m.cfg.Prompt, prompt.Data{
+			Style:    m.cfg.Style.Instructions(),
+			Language: m.cfg.Language,
+			Format:   m.cfg.Format,
+			Scope:    promptScope(m.cfg, changes),
+			Diff:     diff,
+			Files:    changes,
+		})
+		if err != nil {
+			return errMsg{err}
+		}
+
+		resp, err := llmprovider.Generate(m.ctx, m.llmProvider, m.cfg.Structured, "", []llmprovider.Message{
+			{Role: llmprovider.RoleUser, Content: text},
+		})
+		if err != nil {
+			return errMsg{err}
+		}
+
+		return rewordProposalMsg{index: index, message: m.cfg.Layout.Apply(formatResponse(m.cfg, resp, changedPaths(changes)))}
+	}
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+func (m *RewordModel) Err() error {
+	return m.err
+}
+
+func (m *RewordModel) Action() Action {
+	return m.action
+}
+
+// Messages returns the accepted messages, keyed by commit hash.
+func (m *RewordModel) Messages() map[string]string {
+	messages := make(map[string]string)
+	for i, commit := range m.commits {
+		if m.decisions[i] == accepted && strings.TrimSpace(m.proposals[i]) != "" {
+			messages[commit.Hash] = m.proposals[i]
+		}
+	}
+	return messages
+}
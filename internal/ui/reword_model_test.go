@@ -0,0 +1,135 @@
+package ui
+
+import (
+	"context"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rm-hull/git-commit-summary/internal/config"
+	"github.com/rm-hull/git-commit-summary/internal/interfaces"
+	"github.com/rm-hull/git-commit-summary/internal/keys"
+)
+
+func TestRewordModel(t *testing.T) {
+	commits := []interfaces.Commit{
+		{Hash: "aaa", Message: "wip"},
+		{Hash: "bbb", Message: "fix"},
+		{Hash: "ccc", Message: "more"},
+	}
+
+	reviewing := func() *RewordModel {
+		m := InitialRewordModel(context.Background(), new(MockLLMProvider), new(MockGitClient), &config.Config{Keys: keys.Default()}, commits)
+		m.proposals = []string{"feat: one", "fix: two", "chore: three"}
+		m.state = showRewordReview
+		return m
+	}
+
+	key := func(k string) tea.KeyMsg {
+		if k == "enter" {
+			return tea.KeyMsg{Type: tea.KeyEnter}
+		}
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+	}
+
+	t.Run("Proposals are generated in turn", func(t *testing.T) {
+		m := reviewing()
+		m.state = showRewordSpinner
+
+		_, cmd := m.Update(rewordProposalMsg{index: 2, message: "chore: three"})
+
+		assert.Nil(t, cmd)
+		assert.Equal(t, showRewordReview, m.state)
+	})
+
+	t.Run("Accept, skip and edit", func(t *testing.T) {
+		m := reviewing()
+
+		m.Update(key("enter"))
+		assert.Equal(t, 1, m.cursor)
+		m.Update(key("s"))
+		assert.Equal(t, 2, m.cursor)
+
+		m.Update(key("e"))
+		assert.Equal(t, showRewordEditor, m.state)
+		m.Update(commitMsg("chore: edited\n"))
+
+		assert.Equal(t, showRewordConfirm, m.state)
+		assert.Equal(t, None, m.Action())
+		assert.Contains(t, m.View(), "rewording 2 of 3 commits")
+		assert.Contains(t, m.View(), "ccc chore: edited")
+		assert.NotContains(t, m.View(), "fix: two")
+
+		_, cmd := m.Update(key("y"))
+		_, cmd = m.Update(cmd())
+		assert.Equal(t, Commit, m.Action())
+		assert.IsType(t, tea.QuitMsg{}, cmd())
+		assert.Equal(t, map[string]string{
+			"aaa": "feat: one",
+			"ccc": "chore: edited",
+		}, m.Messages())
+	})
+
+	t.Run("Review again before rewriting", func(t *testing.T) {
+		m := reviewing()
+		m.Update(key("enter"))
+		m.Update(key("enter"))
+		m.Update(key("enter"))
+		assert.Equal(t, showRewordConfirm, m.state)
+
+		_, cmd := m.Update(key("r"))
+		m.Update(cmd())
+		assert.Equal(t, showRewordReview, m.state)
+		assert.Equal(t, None, m.Action())
+
+		m.Update(key("s"))
+		assert.Equal(t, showRewordConfirm, m.state)
+		_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		m.Update(cmd())
+		assert.Equal(t, Abort, m.Action())
+	})
+
+	t.Run("Nothing to rewrite", func(t *testing.T) {
+		m := reviewing()
+		m.Update(key("s"))
+		m.Update(key("s"))
+		_, cmd := m.Update(key("s"))
+
+		assert.Equal(t, Commit, m.Action())
+		assert.IsType(t, tea.QuitMsg{}, cmd())
+		assert.Empty(t, m.Messages())
+	})
+
+	t.Run("Cancelled edit returns to review", func(t *testing.T) {
+		m := reviewing()
+		m.Update(key("e"))
+		m.Update(abortMsg{})
+
+		assert.Equal(t, showRewordReview, m.state)
+		assert.Equal(t, None, m.Action())
+	})
+
+	t.Run("Abort", func(t *testing.T) {
+		m := reviewing()
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+		assert.Equal(t, Abort, m.Action())
+		assert.IsType(t, tea.QuitMsg{}, cmd())
+		assert.Empty(t, m.Messages())
+	})
+
+	t.Run("Uses the configured abort key", func(t *testing.T) {
+		keyMap, err := keys.Parse("abort=ctrl+q")
+		assert.NoError(t, err)
+		m := InitialRewordModel(context.Background(), new(MockLLMProvider), new(MockGitClient), &config.Config{Keys: keyMap}, commits)
+		m.proposals = []string{"feat: one", "fix: two", "chore: three"}
+		m.state = showRewordReview
+		assert.Contains(t, m.View(), "CTRL+Q:abort")
+
+		m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		assert.Equal(t, None, m.Action())
+		m.Update(tea.KeyMsg{Type: tea.KeyCtrlQ})
+		assert.Equal(t, Abort, m.Action())
+	})
+}
@@ -0,0 +1,316 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/cockroachdb/errors"
+	"github.com/rm-hull/git-commit-summary/internal/analysis"
+	"github.com/rm-hull/git-commit-summary/internal/config"
+	"github.com/rm-hull/git-commit-summary/internal/interfaces"
+	"github.com/rm-hull/git-commit-summary/internal/keys"
+	llmprovider "github.com/rm-hull/git-commit-summary/internal/llm_provider"
+	"github.com/rm-hull/git-commit-summary/internal/prompt"
+	"github.com/rm-hull/git-commit-summary/internal/split"
+)
+
+type splitState int
+
+const (
+	showSplitSpinner splitState = iota
+	showSplitPlan
+	showSplitEditor
+)
+
+type (
+	splitChangesMsg struct {
+		diff    string
+		changes []interfaces.FileChange
+		hunks   map[string]int
+	}
+	splitPlanMsg []split.Commit
+)
+
+// splitKeyMap holds the key bindings of the plan view, where committing and
+// aborting use the keys configured for the commit message editor.
+type splitKeyMap struct {
+	Up     key.Binding
+	Down   key.Binding
+	Edit   key.Binding
+	Commit key.Binding
+	Abort  key.Binding
+}
+
+func newSplitKeyMap(keyMap keys.Map) splitKeyMap {
+	return splitKeyMap{
+		Up:     key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑", "up")),
+		Down:   key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓", "down")),
+		Edit:   key.NewBinding(key.WithKeys("e", "enter"), key.WithHelp("e", "edit")),
+		Commit: key.NewBinding(key.WithKeys(keyMap.Commit.Keys()...), key.WithHelp(keyMap.Commit.Help().Key, "commit all")),
+		Abort:  key.NewBinding(key.WithKeys(keyMap.Abort.Keys()...), key.WithHelp(keyMap.Abort.Help().Key, "abort")),
+	}
+}
+
+// SplitModel proposes how to split the staged changes into several commits,
+// and lets the user review and edit the proposed commit messages.
+type SplitModel struct {
+	ctx            context.Context
+	state          splitState
+	llmProvider    llmprovider.Provider
+	gitClient      interfaces.GitClient
+	cfg            *config.Config
+	spinner        spinner.Model
+	spinnerMessage string
+	changes        []interfaces.FileChange
+	hunks          map[string]int
+	commits        []split.Commit
+	cursor         int
+	editView       tea.Model
+	keys           splitKeyMap
+	width          int
+	height         int
+	action         Action
+	err            error
+}
+
+func InitialSplitModel(
+	ctx context.Context,
+	llmProvider llmprovider.Provider,
+	gitClient interfaces.GitClient,
+	cfg *config.Config,
+) *SplitModel {
+	return &SplitModel{
+		ctx:            ctx,
+		state:          showSplitSpinner,
+		llmProvider:    llmProvider,
+		gitClient:      gitClient,
+		cfg:            cfg,
+		spinner:        spinner.New(spinner.WithSpinner(spinner.MiniDot)),
+		spinnerMessage: Magenta.Render("Running git commands to determine staged changes..."),
+		keys:           newSplitKeyMap(cfg.Keys),
+		action:         None,
+	}
+}
+
+func (m *SplitModel) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, m.loadChanges)
+}
+
+func (m *SplitModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		resize(m.editView, m.width, m.height-1) // less the heading
+
+	case tea.KeyMsg:
+		switch m.state {
+		case showSplitSpinner:
+			if msg.Type == tea.KeyCtrlC {
+				m.action = Abort
+				return m, tea.Quit
+			}
+
+		case showSplitPlan:
+			switch {
+			case key.Matches(msg, m.keys.Up):
+				if m.cursor > 0 {
+					m.cursor--
+				}
+			case key.Matches(msg, m.keys.Down):
+				if m.cursor < len(m.commits)-1 {
+					m.cursor++
+				}
+			case key.Matches(msg, m.keys.Edit):
+				m.state = showSplitEditor
+				m.editView, m.err = newCommitView(m.cfg, m.commits[m.cursor].Message)
+				if m.err != nil {
+					return m, tea.Quit
+				}
+				resize(m.editView, m.width, m.height-1)
+				return m, m.editView.Init()
+			case key.Matches(msg, m.keys.Commit):
+				m.action = Commit
+				return m, tea.Quit
+			case msg.Type == tea.KeyCtrlC, key.Matches(msg, m.keys.Abort):
+				m.action = Abort
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+
+	case splitChangesMsg:
+		if splitUnits(msg.changes, msg.hunks) < 2 {
+			m.err = errors.New("at least two staged files or hunks are needed to split a commit")
+			return m, tea.Quit
+		}
+		m.changes, m.hunks = msg.changes, msg.hunks
+		m.spinnerMessage = fmt.Sprintf("%s%s%s",
+			Blue.Render("Proposing how to split the staged changes (using: "),
+			BoldBlue.Render(m.llmProvider.Model()),
+			Blue.Render(")"),
+		)
+		return m, m.generatePlan(msg.diff)
+
+	case splitPlanMsg:
+		m.state = showSplitPlan
+		m.commits = msg
+		return m, nil
+
+	case commitMsg:
+		// the edited message for the selected commit has been accepted
+		m.commits[m.cursor].Message = strings.TrimSpace(string(msg))
+		m.state = showSplitPlan
+		return m, nil
+
+	case abortMsg, regenerateMsg:
+		// editing a message was cancelled, regeneration is not supported here
+		m.state = showSplitPlan
+		return m, nil
+
+	case errMsg:
+		m.err = msg.err
+		return m, tea.Quit
+	}
+
+	var cmd tea.Cmd
+	switch m.state {
+	case showSplitSpinner:
+		m.spinner, cmd = m.spinner.Update(msg)
+	case showSplitEditor:
+		m.editView, cmd = m.editView.Update(msg)
+	}
+	return m, cmd
+}
+
+func (m *SplitModel) View() string {
+	switch m.state {
+	case showSplitSpinner:
+		return m.spinner.View() + " " + m.spinnerMessage
+	case showSplitPlan:
+		return m.planView()
+	case showSplitEditor:
+		return Magenta.Render(fmt.Sprintf("Editing commit %d of %d:", m.cursor+1, len(m.commits))) +
+			"\n" + m.editView.View()
+	default:
+		return ""
+	}
+}
+
+func (m *SplitModel) planView() string {
+	var sb strings.Builder
+	sb.WriteString(Magenta.Render(fmt.Sprintf("The staged changes will be split into %d commits:", len(m.commits))) + "\n")
+
+	for i, commit := range m.commits {
+		subject, _, _ := strings.Cut(commit.Message, "\n")
+		files := fmt.Sprintf("(%d files)", len(commit.Paths()))
+		if i == m.cursor {
+			sb.WriteString(fmt.Sprintf("%s%d. %s %s\n", Cyan.Render("❯ "), i+1, subject, Cyan.Render(files)))
+		} else {
+			sb.WriteString(fmt.Sprintf("  %d. %s %s\n", i+1, subject, Cyan.Render(files)))
+		}
+	}
+
+	selected := m.commits[m.cursor]
+	var details strings.Builder
+	details.WriteString(selected.Message + "\n")
+	for _, file := range selected.Files {
+		details.WriteString("\n" + Cyan.Render("  "+file))
+	}
+
+	sb.WriteString(lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(activeTheme.Cyan).
+		Padding(0, 1).
+		Width(boxWidth(m.width)).
+		Render(details.String()) + "\n")
+
+	sb.WriteString(fitWidth(helpLine(
+		helpKey(m.keys.Up)+"/"+helpKey(m.keys.Down)+":select",
+		helpEntry(m.keys.Edit, true),
+		helpEntry(m.keys.Commit, true),
+		helpEntry(m.keys.Abort, true)), m.width))
+
+	return sb.String()
+}
+
+func (m *SplitModel) loadChanges() tea.Msg {
+	if err := m.gitClient.IsInWorkTree(); err != nil {
+		return errMsg{err}
+	}
+	changes, err := m.gitClient.StagedChanges()
+	if err != nil {
+		return errMsg{err}
+	}
+	diff, err := m.gitClient.Diff()
+	if err != nil {
+		return errMsg{err}
+	}
+	hunks := split.CountHunks(diff)
+	diff = analysis.Annotate(m.gitClient, "", diff, m.cfg.DiffContext)
+	return splitChangesMsg{diff: split.LabelHunks(diff, changes), changes: changes, hunks: hunks}
+}
+
+// splitUnits counts what may go in separate commits: the hunks of a file that
+// can be split, or else the file.
+func splitUnits(changes []interfaces.FileChange, hunks map[string]int) int {
+	units := 0
+	for _, change := range changes {
+		if split.Splittable(change) && hunks[change.Path] > 1 {
+			units += hunks[change.Path]
+		} else {
+			units++
+		}
+	}
+	return units
+}
+
+func (m *SplitModel) generatePlan(diff string) tea.Cmd {
+	return func() tea.Msg {
+		text, err := prompt.Render(m.cfg.SplitPrompt, prompt.Data{
+			Style:    m.cfg.Style.Instructions(),
+			Language: m.cfg.Language,
+			Format:   m.cfg.Format,
+			Diff:     diff,
+			Files:    m.changes,
+		})
+		if err != nil {
+			return errMsg{err}
+		}
+
+		resp, err := m.llmProvider.Call(m.ctx, "", text)
+		if err != nil {
+			return errMsg{err}
+		}
+
+		commits, err := split.ParsePlan(llmprovider.Sanitize(resp), m.changes, m.hunks)
+		if err != nil {
+			return errMsg{err}
+		}
+
+		for i := range commits {
+			commits[i].Message = m.cfg.Layout.Apply(formatResponse(m.cfg, commits[i].Message, commits[i].Paths()))
+		}
+		return splitPlanMsg(commits)
+	}
+}
+
+func (m *SplitModel) Err() error {
+	return m.err
+}
+
+func (m *SplitModel) Action() Action {
+	return m.action
+}
+
+func (m *SplitModel) Commits() []split.Commit {
+	return m.commits
+}
+
+func (m *SplitModel) Changes() []interfaces.FileChange {
+	return m.changes
+}
@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"context"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rm-hull/git-commit-summary/internal/config"
+	"github.com/rm-hull/git-commit-summary/internal/keys"
+	"github.com/rm-hull/git-commit-summary/internal/split"
+)
+
+func TestSplitModel(t *testing.T) {
+	planning := func(keyMap keys.Map) *SplitModel {
+		m := InitialSplitModel(context.Background(), new(MockLLMProvider), new(MockGitClient), &config.Config{Keys: keyMap})
+		m.Update(splitPlanMsg{
+			{Message: "feat: one", Files: []string{"a.go"}},
+			{Message: "fix: two", Files: []string{"b.go"}},
+		})
+		return m
+	}
+
+	t.Run("Select and edit", func(t *testing.T) {
+		m := planning(keys.Default())
+		assert.Contains(t, m.View(), "CTRL+X:commit all")
+
+		m.Update(tea.KeyMsg{Type: tea.KeyDown})
+		assert.Equal(t, 1, m.cursor)
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+		assert.Equal(t, showSplitEditor, m.state)
+		m.Update(commitMsg("fix: edited\n"))
+
+		assert.Equal(t, showSplitPlan, m.state)
+		assert.Equal(t, []split.Commit{
+			{Message: "feat: one", Files: []string{"a.go"}},
+			{Message: "fix: edited", Files: []string{"b.go"}},
+		}, m.Commits())
+	})
+
+	t.Run("Uses the configured keys", func(t *testing.T) {
+		keyMap, err := keys.Parse("commit=ctrl+s", "abort=ctrl+q")
+		assert.NoError(t, err)
+		m := planning(keyMap)
+		assert.Contains(t, m.View(), "CTRL+S:commit all")
+		assert.Contains(t, m.View(), "CTRL+Q:abort")
+
+		m.Update(tea.KeyMsg{Type: tea.KeyCtrlX})
+		m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		assert.Equal(t, None, m.Action())
+
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+		assert.Equal(t, Commit, m.Action())
+		assert.IsType(t, tea.QuitMsg{}, cmd())
+	})
+}
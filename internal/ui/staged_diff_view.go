@@ -0,0 +1,200 @@
+package ui
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rm-hull/git-commit-summary/internal/keys"
+	"github.com/rm-hull/git-commit-summary/internal/split"
+)
+
+const (
+	stagedDiffWidth  = 80
+	stagedDiffHeight = 20
+)
+
+var (
+	hunkHeading = regexp.MustCompile(`^@@ [^@]* @@ ?(.*)$`)
+	identifier  = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+)
+
+// diffFile is where a file's changes start in the diff, and those of each of
+// its hunks.
+type diffFile struct {
+	path  string
+	line  int
+	hunks []diffHunk
+}
+
+type diffHunk struct {
+	heading string // the enclosing declarations, as annotated
+	line    int
+}
+
+// stagedDiffViewModel is the pane, opened beside the commit view, for reading
+// the diff the message was generated from, without leaving the editor.
+type stagedDiffViewModel struct {
+	viewport viewport.Model
+	files    []diffFile
+	file     int
+	keys     keys.ChangesMap
+}
+
+func initialStagedDiffViewModel(diff string, keyMap keys.ChangesMap) *stagedDiffViewModel {
+	diff = strings.TrimRight(diff, "\n")
+
+	vp := viewport.New(stagedDiffWidth, stagedDiffHeight)
+	vp.SetContent(highlightDiff(diff))
+
+	return &stagedDiffViewModel{
+		viewport: vp,
+		files:    diffFiles(diff),
+		keys:     keyMap,
+	}
+}
+
+func diffFiles(diff string) []diffFile {
+	var files []diffFile
+	for i, text := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(text, "diff --git "):
+			// the new path, as the old one is /dev/null for an added file
+			files = append(files, diffFile{path: split.DiffPath(text), line: i})
+
+		case len(files) > 0 && hunkHeading.MatchString(text):
+			file := &files[len(files)-1]
+			heading := hunkHeading.FindStringSubmatch(text)[1]
+			file.hunks = append(file.hunks, diffHunk{heading: heading, line: i})
+		}
+	}
+	return files
+}
+
+// highlightDiff colors the diff for the terminal in the style of the theme,
+// one line of output for each line of the diff, or leaves it as it is should
+// that fail, or the theme have no colors.
+func highlightDiff(diff string) string {
+	lexer := lexers.Get("diff")
+	if lexer == nil || activeTheme.Chroma == "" {
+		return diff
+	}
+	iterator, err := lexer.Tokenise(nil, diff)
+	if err != nil {
+		return diff
+	}
+
+	var sb strings.Builder
+	if err := formatters.TTY256.Format(&sb, styles.Get(activeTheme.Chroma), iterator); err != nil {
+		return diff
+	}
+	return sb.String()
+}
+
+// setSize sets the size of the diff shown, within the border.
+func (m *stagedDiffViewModel) setSize(width, height int) {
+	m.viewport.Width = width
+	m.viewport.Height = height
+}
+
+// jumpTo scrolls to the changes to the file mentioned in the text, such as a
+// line of the commit message, and to the hunk within it that changes an
+// identifier also mentioned, if there is one. It reports whether any file
+// was found.
+func (m *stagedDiffViewModel) jumpTo(text string) bool {
+	index, matched := -1, ""
+	for i, file := range m.files {
+		// the full path is preferred, but a message often names just the file
+		for _, name := range []string{file.path, path.Base(file.path)} {
+			if len(name) > len(matched) && strings.Contains(text, name) {
+				index, matched = i, name
+			}
+		}
+	}
+	if index < 0 {
+		return false
+	}
+
+	m.file = index
+	file := m.files[index]
+	words := identifier.FindAllString(strings.ReplaceAll(text, matched, ""), -1)
+	for _, hunk := range file.hunks {
+		for _, name := range identifier.FindAllString(hunk.heading, -1) {
+			if len(name) > 2 && slices.Contains(words, name) {
+				m.viewport.SetYOffset(hunk.line)
+				return true
+			}
+		}
+	}
+	m.viewport.SetYOffset(file.line)
+	return true
+}
+
+// showFile scrolls to the start of the changes to another file.
+func (m *stagedDiffViewModel) showFile(index int) {
+	if index < 0 || index >= len(m.files) {
+		return
+	}
+	m.file = index
+	m.viewport.SetYOffset(m.files[index].line)
+}
+
+// Update handles a key press, returning true once the pane should be closed.
+func (m *stagedDiffViewModel) Update(msg tea.KeyMsg) (bool, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Back):
+		return true, nil
+	case key.Matches(msg, m.keys.NextFile):
+		m.showFile(m.file + 1)
+		return false, nil
+	case key.Matches(msg, m.keys.PreviousFile):
+		m.showFile(m.file - 1)
+		return false, nil
+	}
+
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+
+	// follow the file being scrolled through
+	for i, file := range m.files {
+		if file.line <= m.viewport.YOffset {
+			m.file = i
+		}
+	}
+	return false, cmd
+}
+
+func (m *stagedDiffViewModel) View() string {
+	title := " Changes "
+	if len(m.files) > 0 {
+		title = fmt.Sprintf(" %s (%d of %d) ", m.files[m.file].path, m.file+1, len(m.files))
+	}
+
+	border := lipgloss.RoundedBorder()
+	if width := lipgloss.Width(title); width <= m.viewport.Width+2 {
+		border.Top = title + strings.Repeat("─", m.viewport.Width-width+2)
+	}
+
+	return lipgloss.NewStyle().
+		BorderStyle(border).
+		BorderForeground(activeTheme.Cyan).
+		Padding(0, 1).
+		Render(m.viewport.View())
+}
+
+func (m *stagedDiffViewModel) helpTextView() string {
+	return helpLine(
+		BoldYellow.Render("↑/↓")+":scroll",
+		helpEntry(m.keys.NextFile, true),
+		helpEntry(m.keys.PreviousFile, true),
+		helpEntry(m.keys.Back, true))
+}
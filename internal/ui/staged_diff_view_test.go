@@ -0,0 +1,121 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rm-hull/git-commit-summary/internal/keys"
+	"github.com/rm-hull/git-commit-summary/internal/theme"
+)
+
+const stagedDiff = `diff --git a/README.md b/README.md
+index 1111111..2222222 100644
+--- a/README.md
++++ b/README.md
+@@ -1,3 +1,3 @@
+ # Title
+-old
++new
+diff --git a/internal/ui/model.go b/internal/ui/model.go
+index 3333333..4444444 100644
+--- a/internal/ui/model.go
++++ b/internal/ui/model.go
+@@ -10,3 +10,4 @@ Model
+ type Model struct {
++	diff string
+ }
+@@ -40,3 +41,4 @@ Model.showCommitView
+ func (m *Model) showCommitView() {
++	return
+ }
+diff --git a/docs/new.md b/docs/new.md
+new file mode 100644
+--- /dev/null
++++ b/docs/new.md
+@@ -0,0 +1 @@
++new
+`
+
+func TestStagedDiffViewModel(t *testing.T) {
+	t.Run("Files and hunks", func(t *testing.T) {
+		m := initialStagedDiffViewModel(stagedDiff, keys.Default().ChangesPanel)
+		assert.Equal(t, []diffFile{
+			{path: "README.md", line: 0, hunks: []diffHunk{{heading: "", line: 4}}},
+			{path: "internal/ui/model.go", line: 8, hunks: []diffHunk{
+				{heading: "Model", line: 12},
+				{heading: "Model.showCommitView", line: 16},
+			}},
+			{path: "docs/new.md", line: 20, hunks: []diffHunk{{heading: "", line: 24}}},
+		}, m.files)
+	})
+
+	t.Run("A path with b/ in it", func(t *testing.T) {
+		m := initialStagedDiffViewModel("diff --git a/plan b/notes.md b/plan b/notes.md\n--- a/plan b/notes.md\n+++ b/plan b/notes.md\n@@ -1 +1 @@\n-a\n+b\n", keys.Default().ChangesPanel)
+		assert.Equal(t, "plan b/notes.md", m.files[0].path)
+	})
+
+	t.Run("Highlighting keeps the lines", func(t *testing.T) {
+		diff := strings.TrimRight(stagedDiff, "\n")
+		assert.Equal(t, strings.Count(diff, "\n"), strings.Count(strings.TrimRight(highlightDiff(diff), "\n"), "\n"))
+	})
+
+	t.Run("No highlighting without colors", func(t *testing.T) {
+		ApplyTheme(theme.None())
+		defer func() {
+			dark, _ := theme.Preset("dark")
+			ApplyTheme(dark)
+		}()
+
+		diff := strings.TrimRight(stagedDiff, "\n")
+		assert.Equal(t, diff, highlightDiff(diff))
+	})
+
+	t.Run("Jump to a file mentioned", func(t *testing.T) {
+		m := initialStagedDiffViewModel(stagedDiff, keys.Default().ChangesPanel)
+		m.viewport.Height = 5
+		assert.True(t, m.jumpTo("* Add the diff to the Model in model.go"))
+		assert.Equal(t, 1, m.file)
+		assert.Equal(t, 12, m.viewport.YOffset)
+
+		assert.True(t, m.jumpTo("* Change internal/ui/model.go so that showCommitView returns"))
+		assert.Equal(t, 16, m.viewport.YOffset)
+
+		assert.False(t, m.jumpTo("feat: nothing in particular"))
+		assert.Equal(t, 1, m.file)
+	})
+
+	t.Run("Next and previous file", func(t *testing.T) {
+		m := initialStagedDiffViewModel(stagedDiff, keys.Default().ChangesPanel)
+		m.viewport.Height = 5
+
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+		assert.Equal(t, 1, m.file)
+		assert.Equal(t, 8, m.viewport.YOffset)
+		assert.Contains(t, m.View(), "internal/ui/model.go (2 of 3)")
+
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+		assert.Equal(t, 0, m.file)
+
+		done, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		assert.True(t, done)
+	})
+
+	t.Run("Rebound keys", func(t *testing.T) {
+		keyMap, err := keys.Parse("changes-next-file=]", "changes-previous-file=[")
+		assert.NoError(t, err)
+		m := initialStagedDiffViewModel(stagedDiff, keyMap.ChangesPanel)
+		assert.Contains(t, m.helpTextView(), "]:next file")
+		assert.Contains(t, m.helpTextView(), "[:previous file")
+
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+		assert.Equal(t, 0, m.file)
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{']'}})
+		assert.Equal(t, 1, m.file)
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'['}})
+		assert.Equal(t, 0, m.file)
+	})
+}
@@ -0,0 +1,196 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/rm-hull/git-commit-summary/internal/keys"
+	"github.com/rm-hull/git-commit-summary/internal/message"
+)
+
+var nameAndEmail = regexp.MustCompile(`^[^<>]+<[^<>@\s]+@[^<>\s]+>$`)
+
+// The keys of the input for a trailer, which are fixed as the rest are typed.
+var (
+	confirmTrailer = key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "add"))
+	cancelTrailer  = key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel"))
+)
+
+type trailerItem struct {
+	trailer  message.Trailer
+	selected bool
+}
+
+// trailerViewModel is the panel, opened from the commit view, for choosing
+// the trailers to append to the commit message: a sign-off, co-authors
+// suggested from the recent commit authors, and any others typed in.
+type trailerViewModel struct {
+	items  []trailerItem
+	cursor int
+	input  textinput.Model
+	adding bool
+	err    string
+	keys   keys.TrailerMap
+}
+
+func initialTrailerViewModel(keyMap keys.TrailerMap) *trailerViewModel {
+	ti := textinput.New()
+	ti.Placeholder = "Name <email>, or Key: value"
+	ti.CharLimit = 200
+	ti.Width = 72
+
+	return &trailerViewModel{input: ti, keys: keyMap}
+}
+
+// suggest adds a sign-off (selected if requested) for the given identity, and
+// unselected co-authors, unless they are already listed.
+func (m *trailerViewModel) suggest(identity string, signOff bool, coAuthors []string) {
+	if identity != "" && !m.contains(message.SignedOffBy, identity) {
+		signOffItem := trailerItem{
+			trailer:  message.Trailer{Key: message.SignedOffBy, Value: identity},
+			selected: signOff,
+		}
+		m.items = append([]trailerItem{signOffItem}, m.items...)
+	}
+
+	for _, author := range coAuthors {
+		if !m.contains(message.CoAuthoredBy, author) {
+			m.items = append(m.items, trailerItem{
+				trailer: message.Trailer{Key: message.CoAuthoredBy, Value: author},
+			})
+		}
+	}
+}
+
+func (m *trailerViewModel) contains(key, value string) bool {
+	for _, item := range m.items {
+		if item.trailer.Key == key && item.trailer.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Selected returns the trailers to append to the commit message.
+func (m *trailerViewModel) Selected() []message.Trailer {
+	var trailers []message.Trailer
+	for _, item := range m.items {
+		if item.selected {
+			trailers = append(trailers, item.trailer)
+		}
+	}
+	return trailers
+}
+
+// Update handles a key press, returning true once the panel should be closed.
+func (m *trailerViewModel) Update(msg tea.KeyMsg) (bool, tea.Cmd) {
+	if m.adding {
+		switch {
+		case key.Matches(msg, confirmTrailer):
+			return false, m.add(m.input.Value())
+		case key.Matches(msg, cancelTrailer):
+			m.closeInput()
+			return false, nil
+		}
+
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		return false, cmd
+	}
+
+	switch {
+	case key.Matches(msg, m.keys.Up):
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case key.Matches(msg, m.keys.Down):
+		if m.cursor < len(m.items)-1 {
+			m.cursor++
+		}
+	case key.Matches(msg, m.keys.Toggle):
+		if len(m.items) > 0 {
+			m.items[m.cursor].selected = !m.items[m.cursor].selected
+		}
+	case key.Matches(msg, m.keys.Add):
+		m.adding = true
+		m.input.Reset()
+		return false, m.input.Focus()
+	case key.Matches(msg, m.keys.Done):
+		return true, nil
+	}
+	return false, nil
+}
+
+// add parses the typed trailer, where a bare `Name <email>` is taken to be a
+// co-author.
+func (m *trailerViewModel) add(text string) tea.Cmd {
+	text = strings.TrimSpace(text)
+
+	trailer, ok := message.ParseTrailer(text)
+	if nameAndEmail.MatchString(text) {
+		trailer = message.Trailer{Key: message.CoAuthoredBy, Value: text}
+	} else if !ok {
+		m.err = "Expected Name <email>, or Key: value"
+		return nil
+	}
+
+	for i, item := range m.items {
+		if item.trailer == trailer {
+			m.items[i].selected = true
+			m.cursor = i
+			m.closeInput()
+			return nil
+		}
+	}
+
+	m.items = append(m.items, trailerItem{trailer: trailer, selected: true})
+	m.cursor = len(m.items) - 1
+	m.closeInput()
+	return nil
+}
+
+func (m *trailerViewModel) closeInput() {
+	m.adding = false
+	m.err = ""
+	m.input.Blur()
+}
+
+func (m *trailerViewModel) View() string {
+	var sb strings.Builder
+	sb.WriteString(Magenta.Render("Trailers to append to the commit message:") + "\n")
+
+	if len(m.items) == 0 {
+		sb.WriteString(Cyan.Render(fmt.Sprintf("  (no suggestions, press %s to add one)",
+			strings.ToUpper(m.keys.Add.Help().Key))) + "\n")
+	}
+	for i, item := range m.items {
+		cursor := "  "
+		if i == m.cursor && !m.adding {
+			cursor = Cyan.Render("❯ ")
+		}
+		checkbox := "[ ]"
+		if item.selected {
+			checkbox = "[x]"
+		}
+		sb.WriteString(fmt.Sprintf("%s%s %s\n", cursor, checkbox, item.trailer))
+	}
+
+	if m.adding {
+		sb.WriteString("\n" + m.input.View() + "\n")
+		if m.err != "" {
+			sb.WriteString(BoldRed.Render(m.err) + "\n")
+		}
+		sb.WriteString(helpLine(helpEntry(confirmTrailer, true), helpEntry(cancelTrailer, true)))
+		return sb.String()
+	}
+
+	sb.WriteString(helpLine(
+		helpEntry(m.keys.Toggle, true),
+		helpEntry(m.keys.Add, true),
+		helpEntry(m.keys.Done, true)))
+	return sb.String()
+}
@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rm-hull/git-commit-summary/internal/keys"
+	"github.com/rm-hull/git-commit-summary/internal/message"
+)
+
+func typeText(m *trailerViewModel, text string) {
+	for _, r := range text {
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+func TestTrailerViewModel(t *testing.T) {
+	signOff := message.Trailer{Key: message.SignedOffBy, Value: "Me <me@example.com>"}
+	coAuthor := message.Trailer{Key: message.CoAuthoredBy, Value: "Jo <jo@example.com>"}
+
+	t.Run("Suggestions", func(t *testing.T) {
+		m := initialTrailerViewModel(keys.Default().TrailerPanel)
+		m.suggest("Me <me@example.com>", true, []string{"Jo <jo@example.com>"})
+		m.suggest("Me <me@example.com>", true, []string{"Jo <jo@example.com>"})
+
+		assert.Len(t, m.items, 2)
+		assert.Equal(t, []message.Trailer{signOff}, m.Selected())
+	})
+
+	t.Run("Toggle a co-author", func(t *testing.T) {
+		m := initialTrailerViewModel(keys.Default().TrailerPanel)
+		m.suggest("Me <me@example.com>", false, []string{"Jo <jo@example.com>"})
+
+		m.Update(tea.KeyMsg{Type: tea.KeyDown})
+		m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+
+		assert.Equal(t, []message.Trailer{coAuthor}, m.Selected())
+	})
+
+	t.Run("Add a co-author by name and email", func(t *testing.T) {
+		m := initialTrailerViewModel(keys.Default().TrailerPanel)
+
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+		typeText(m, "Jo <jo@example.com>")
+		done, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		assert.False(t, done)
+		assert.False(t, m.adding)
+		assert.Equal(t, []message.Trailer{coAuthor}, m.Selected())
+	})
+
+	t.Run("Add any other trailer", func(t *testing.T) {
+		m := initialTrailerViewModel(keys.Default().TrailerPanel)
+
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+		typeText(m, "Refs: #123")
+		m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		assert.Equal(t, []message.Trailer{{Key: "Refs", Value: "#123"}}, m.Selected())
+	})
+
+	t.Run("Reject an invalid trailer", func(t *testing.T) {
+		m := initialTrailerViewModel(keys.Default().TrailerPanel)
+
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+		typeText(m, "not a trailer")
+		m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		assert.True(t, m.adding)
+		assert.NotEmpty(t, m.err)
+		assert.Empty(t, m.Selected())
+	})
+
+	t.Run("Close the panel", func(t *testing.T) {
+		m := initialTrailerViewModel(keys.Default().TrailerPanel)
+		done, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		assert.True(t, done)
+	})
+
+	t.Run("Rebound keys", func(t *testing.T) {
+		keyMap, err := keys.Parse("trailer-add=+", "trailer-done=q")
+		assert.NoError(t, err)
+		m := initialTrailerViewModel(keyMap.TrailerPanel)
+		assert.Contains(t, m.View(), "press + to add one")
+		assert.Contains(t, m.View(), "+:add")
+		assert.Contains(t, m.View(), "Q:done")
+
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+		assert.False(t, m.adding)
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'+'}})
+		assert.True(t, m.adding)
+		m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+		done, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		assert.False(t, done)
+		done, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}})
+		assert.True(t, done)
+	})
+}
+
+func TestCommitViewModel_Trailers(t *testing.T) {
+	m, err := initialCommitViewModel("feat: pair on the thing\n\nLong enough to wrap, or so it seems.", false, keys.Default())
+	assert.NoError(t, err)
+	m.trailers = initialTrailerViewModel(keys.Default().TrailerPanel)
+	m.trailers.suggest("Me <me@example.com>", true, []string{"Jo <jo@example.com>"})
+
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	assert.True(t, m.editingTrailers)
+	assert.Contains(t, m.View(), "Trailers to append")
+
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	assert.False(t, m.editingTrailers)
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlX})
+	assert.Equal(t, commitMsg(
+		"feat: pair on the thing\n\nLong enough to wrap, or so it seems.\n\n"+
+			"Signed-off-by: Me <me@example.com>\n"+
+			"Co-authored-by: Jo <jo@example.com>"), cmd())
+}
@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var wordTokens = regexp.MustCompile(`\s+|\S+`)
+
+type diffKind int
+
+const (
+	unchanged diffKind = iota
+	inserted
+	deleted
+)
+
+type diffOp struct {
+	kind diffKind
+	text string
+}
+
+// wordDiff compares two versions of a message word by word, using their
+// longest common subsequence. The whitespace between words is compared as
+// well, so that a reflowed paragraph shows where its lines now break.
+func wordDiff(old, new string) []diffOp {
+	a := wordTokens.FindAllString(old, -1)
+	b := wordTokens.FindAllString(new, -1)
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	add := func(kind diffKind, text string) {
+		if n := len(ops); n > 0 && ops[n-1].kind == kind {
+			ops[n-1].text += text
+			return
+		}
+		ops = append(ops, diffOp{kind: kind, text: text})
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			add(unchanged, a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			add(deleted, a[i])
+			i++
+		default:
+			add(inserted, b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		add(deleted, a[i])
+	}
+	for ; j < len(b); j++ {
+		add(inserted, b[j])
+	}
+	return ops
+}
+
+// renderDiff shows the removed words struck through and the added words
+// highlighted, in place in the new version.
+func renderDiff(ops []diffOp) string {
+	var sb strings.Builder
+	for _, op := range ops {
+		switch op.kind {
+		case unchanged:
+			sb.WriteString(op.text)
+		case inserted:
+			sb.WriteString(renderLines(Inserted, op.text))
+		case deleted:
+			if strings.TrimSpace(op.text) != "" {
+				sb.WriteString(renderLines(Deleted, op.text))
+			}
+		}
+	}
+	return sb.String()
+}
+
+// renderLines styles each line of the text separately, so that the styling
+// does not pad out the lines to the same width.
+func renderLines(style lipgloss.Style, text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = style.Render(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
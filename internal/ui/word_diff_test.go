@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWordDiff(t *testing.T) {
+	t.Run("Unchanged", func(t *testing.T) {
+		assert.Equal(t, []diffOp{{kind: unchanged, text: "feat: add cache"}}, wordDiff("feat: add cache", "feat: add cache"))
+	})
+
+	t.Run("ReplacedWords", func(t *testing.T) {
+		assert.Equal(t, []diffOp{
+			{kind: unchanged, text: "feat: add "},
+			{kind: deleted, text: "response"},
+			{kind: inserted, text: "disk"},
+			{kind: unchanged, text: " cache"},
+		}, wordDiff("feat: add response cache", "feat: add disk cache"))
+	})
+
+	t.Run("AddedLines", func(t *testing.T) {
+		assert.Equal(t, []diffOp{
+			{kind: unchanged, text: "fix: typo"},
+			{kind: inserted, text: "\n\nin the README"},
+		}, wordDiff("fix: typo", "fix: typo\n\nin the README"))
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		assert.Equal(t, []diffOp{{kind: inserted, text: "feat: add cache"}}, wordDiff("", "feat: add cache"))
+		assert.Nil(t, wordDiff("", ""))
+	})
+}
+
+func TestRenderDiff(t *testing.T) {
+	ops := []diffOp{
+		{kind: unchanged, text: "feat: add "},
+		{kind: deleted, text: "response"},
+		{kind: deleted, text: " "},
+		{kind: inserted, text: "disk"},
+		{kind: unchanged, text: " cache"},
+	}
+	assert.Equal(t, "feat: add "+Deleted.Render("response")+Inserted.Render("disk")+" cache", renderDiff(ops))
+}
@@ -5,13 +5,16 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/charmbracelet/lipgloss"
 	"github.com/cockroachdb/errors"
 	"github.com/earthboundkid/versioninfo/v2"
 	"github.com/rm-hull/git-commit-summary/internal/app"
+	"github.com/rm-hull/git-commit-summary/internal/cache"
 	"github.com/rm-hull/git-commit-summary/internal/config"
 	"github.com/rm-hull/git-commit-summary/internal/git"
 	"github.com/rm-hull/git-commit-summary/internal/interfaces"
 	llmprovider "github.com/rm-hull/git-commit-summary/internal/llm_provider"
+	"github.com/rm-hull/git-commit-summary/internal/message"
 	"github.com/rm-hull/git-commit-summary/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -22,6 +25,45 @@ func main() {
 
 	var userMessage string
 	var llmProvider string
+	var style string
+	var language string
+	var all bool
+	var includeUntracked bool
+	var force bool
+
+	newApp := func(cmd *cobra.Command) (context.Context, *app.App) {
+		if cmd.Flags().Changed("llm-provider") {
+			cfg.LLMProvider = llmProvider
+		}
+		if cmd.Flags().Changed("style") {
+			cfg.Style, err = message.NewStyle(style, cfg.Style.Template)
+			handleError(err)
+		}
+
+		ui.ApplyTheme(cfg.Theme.Resolve(lipgloss.HasDarkBackground))
+
+		ctx := context.Background()
+
+		provider, err := llmprovider.NewProvider(ctx, cfg)
+		handleError(err)
+
+		scope := git.StagedOnly
+		if all {
+			scope = git.Tracked
+		}
+		if includeUntracked {
+			scope = git.TrackedAndUntracked
+		}
+
+		client := git.NewClient(scope)
+		if cmd.Flags().Changed("lang") {
+			cfg.Language = language
+		} else if repoLanguage, _ := client.ConfigValue("commit-summary.language"); repoLanguage != "" {
+			cfg.Language = repoLanguage
+		}
+
+		return ctx, app.NewApp(provider, client, cfg)
+	}
 
 	rootCmd := &cobra.Command{
 		Use:   "git-commit-summary",
@@ -33,25 +75,87 @@ func main() {
 				os.Exit(0)
 			}
 
-			if cmd.Flags().Changed("llm-provider") {
-				cfg.LLMProvider = llmProvider
+			ctx, application := newApp(cmd)
+			err := application.Run(ctx, userMessage)
+			if err != nil {
+				handleError(err)
 			}
+		},
+	}
 
-			ctx := context.Background()
+	splitCmd := &cobra.Command{
+		Use:   "split",
+		Short: "Split the staged changes into several logical commits",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, application := newApp(cmd)
+			err := application.RunSplit(ctx)
+			if err != nil {
+				handleError(err)
+			}
+		},
+	}
 
-			provider, err := llmprovider.NewProvider(ctx, cfg)
-			handleError(err)
+	squashCmd := &cobra.Command{
+		Use:   "squash <range>",
+		Short: "Write a single commit message for the squashed commits in a range",
+		Long: "Write a single commit message for the squashed commits in a range (e.g. main..feature),\n" +
+			"once their combined changes have been staged with `git merge --squash` or `git reset --soft`.",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, application := newApp(cmd)
+			err := application.RunSquash(ctx, args[0], userMessage)
+			if err != nil {
+				handleError(err)
+			}
+		},
+	}
 
-			application := app.NewApp(provider, git.NewClient(), cfg.Prompt)
-			err = application.Run(ctx, userMessage)
+	rewordCmd := &cobra.Command{
+		Use:   "reword <range>",
+		Short: "Regenerate the messages of existing commits in a range",
+		Long: "Regenerate the messages of existing commits in a range (e.g. main..HEAD) from their own diffs,\n" +
+			"rewriting the history of the current branch. A backup ref of the previous history is kept.",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, application := newApp(cmd)
+			err := application.RunReword(ctx, args[0], force)
 			if err != nil {
 				handleError(err)
 			}
 		},
 	}
+	rewordCmd.Flags().BoolVarP(&force, "force", "f", false, "Reword commits even if they have already been pushed")
+
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the cache of generated commit summaries",
+	}
+
+	cacheClearCmd := &cobra.Command{
+		Use:   "clear",
+		Short: "Remove all cached commit summaries",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := cache.New(cfg.Cache.Dir, cfg.Cache.TTL, cfg.Cache.MaxSize).Clear()
+			handleError(err)
+			fmt.Println("Cache cleared")
+		},
+	}
+
+	cacheCmd.AddCommand(cacheClearCmd)
+	rootCmd.AddCommand(splitCmd, squashCmd, rewordCmd, cacheCmd)
 
 	rootCmd.PersistentFlags().BoolP("version", "v", false, "Display version information")
 	rootCmd.PersistentFlags().StringVarP(&userMessage, "message", "m", "", "Append a message to the commit summary")
+	rootCmd.PersistentFlags().BoolVarP(&all, "all", "a", false, "Include modified and deleted tracked files, staging them on commit")
+	rootCmd.PersistentFlags().BoolVarP(&includeUntracked, "include-untracked", "u", false, "As --all, but also include untracked files")
+	rootCmd.PersistentFlags().BoolVarP(&cfg.SelectFiles, "select", "s", false, "Interactively select which staged files to summarize and commit")
+	rootCmd.PersistentFlags().BoolVarP(&cfg.SignOff, "signoff", "", false, "Add a Signed-off-by trailer for the committer at the end of the commit message")
+	rootCmd.PersistentFlags().BoolVarP(&cfg.Cache.Disabled, "no-cache", "", false, "Always call the LLM, ignoring any previously cached response")
+	rootCmd.PersistentFlags().BoolVarP(&cfg.Theme.NoColor, "no-color", "", cfg.Theme.NoColor, "Draw the UI without colors, as when environment variable NO_COLOR is set")
+	rootCmd.PersistentFlags().StringVarP(&style, "style", "", cfg.Style.Name, "Commit message style: conventional, gitmoji, plain or custom, overrides environment variable COMMIT_STYLE")
+	rootCmd.PersistentFlags().StringVarP(&language, "lang", "", cfg.Language, "Language to write the commit message in, e.g. German, overrides git config commit-summary.language and environment variable COMMIT_LANGUAGE")
 	rootCmd.PersistentFlags().StringVarP(&llmProvider, "llm-provider", "", cfg.LLMProvider, "Use specific LLM provider, overrides environment variable LLM_PROVIDER")
 
 	_ = rootCmd.Execute()